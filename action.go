@@ -8,6 +8,7 @@ import (
 
 	"github.com/rsteube/carapace/internal/cache"
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/env"
 	pkgcache "github.com/rsteube/carapace/pkg/cache"
 	"github.com/rsteube/carapace/pkg/style"
 )
@@ -17,6 +18,8 @@ type Action struct {
 	meta      common.Meta
 	rawValues common.RawValues
 	callback  CompletionCallback
+	spec      []string // static representation for spec export (values or macro)
+	static    bool     // callback has no side effects and can be invoked by the linter
 }
 
 // ActionMap maps Actions to an identifier.
@@ -27,31 +30,85 @@ type CompletionCallback func(c Context) Action
 
 // Cache cashes values of a CompletionCallback for given duration and keys.
 func (a Action) Cache(timeout time.Duration, keys ...pkgcache.Key) Action {
+	_, file, line, _ := runtime.Caller(1) // generate uid from wherever Cache() was called
+	return a.cache(file, line, timeout, 0, keys...)
+}
+
+// CacheStale is like Cache but returns expired values for up to maxStale
+// while refreshing them in a detached background process.
+//
+//	ActionExecCommand("curl", "https://example.com/values")(func(output []byte) Action {
+//		return ActionValues(strings.Split(string(output), "\n")...)
+//	}).CacheStale(time.Hour, 24*time.Hour)
+func (a Action) CacheStale(timeout, maxStale time.Duration, keys ...pkgcache.Key) Action {
+	_, file, line, _ := runtime.Caller(1) // generate uid from wherever CacheStale() was called
+	return a.cache(file, line, timeout, maxStale, keys...)
+}
+
+func (a Action) cache(file string, line int, timeout, maxStale time.Duration, keys ...pkgcache.Key) Action {
 	if a.callback != nil { // only relevant for callback actions
+		a.static = false // cache would be written on invocation (spec is kept as values don't change)
 		cachedCallback := a.callback
-		_, file, line, _ := runtime.Caller(1) // generate uid from wherever Cache() was called
 		a.callback = func(c Context) Action {
-			cacheFile, err := cache.File(file, line, keys...)
+			if env.CacheDisabled() {
+				return cachedCallback(c)
+			}
+
+			cacheKey, err := cache.Key(file, line, keys...)
 			if err != nil {
 				return cachedCallback(c)
 			}
 
-			if cached, err := cache.Load(cacheFile, timeout); err == nil {
-				return Action{meta: cached.Meta, rawValues: cached.Values}
+			refresh := func() Action {
+				invokedAction := (Action{callback: cachedCallback}).Invoke(c)
+				if invokedAction.meta.Messages.IsEmpty() {
+					if cacheKey, err := cache.Key(file, line, keys...); err == nil { // regenerate as cache keys might have changed due to invocation
+						_ = cache.Write(cacheKey, fmt.Sprintf("%v:%v", file, line), invokedAction.export())
+					}
+				}
+				return invokedAction.ToA()
+			}
+
+			if env.CacheRefresh() == cacheKey { // this is the background process refreshing the entry
+				defer cache.Refreshed(cacheKey)
+				return refresh()
 			}
 
-			invokedAction := (Action{callback: cachedCallback}).Invoke(c)
-			if invokedAction.meta.Messages.IsEmpty() {
-				if cacheFile, err := cache.File(file, line, keys...); err == nil { // regenerate as cache keys might have changed due to invocation
-					_ = cache.Write(cacheFile, invokedAction.export())
+			if cached, stale, err := cache.LoadStale(cacheKey, timeout, maxStale); err == nil {
+				if stale && env.CacheRefresh() == "" { // don't spawn further processes from a refreshing one
+					if args := currentCallbackArgs(); args != nil {
+						if err := cache.Refresh(cacheKey, append([]string{"_carapace"}, args...)...); err != nil {
+							LOG.Printf("failed to refresh cache: %v", err)
+						}
+					} else {
+						go refresh() // not a `_carapace` invocation so the process is expected to be long running
+					}
 				}
+				return Action{meta: cached.Meta, rawValues: cached.Values}
 			}
-			return invokedAction.ToA()
+			return refresh()
 		}
 	}
 	return a
 }
 
+func (a Action) withSpec(spec ...string) Action {
+	a.spec = spec
+	return a
+}
+
+func (a Action) asStatic() Action {
+	a.static = true
+	return a
+}
+
+// inherit carries spec and static over from given Action (for modifiers not changing the values).
+func (a Action) inherit(from Action) Action {
+	a.spec = from.spec
+	a.static = from.static
+	return a
+}
+
 // Invoke executes the callback of an action if it exists (supports nesting).
 func (a Action) Invoke(c Context) InvokedAction {
 	if c.Args == nil {
@@ -65,6 +122,9 @@ func (a Action) Invoke(c Context) InvokedAction {
 	}
 
 	if a.rawValues == nil && a.callback != nil {
+		if err := c.Err(); err != nil { // invocation was cancelled
+			return ActionMessage(err.Error()).Invoke(Context{})
+		}
 		result := a.callback(c).Invoke(c)
 		result.meta.Merge(a.meta)
 		return result
@@ -80,7 +140,7 @@ func (a Action) NoSpace(suffixes ...rune) Action {
 		}
 		a.meta.Nospace.Add(suffixes...)
 		return a
-	})
+	}).inherit(a)
 }
 
 // Usage sets the usage.
@@ -97,7 +157,7 @@ func (a Action) UsageF(f func() string) Action {
 			a.meta.Usage = usage
 		}
 		return a
-	})
+	}).inherit(a)
 }
 
 // Style sets the style.
@@ -105,9 +165,13 @@ func (a Action) UsageF(f func() string) Action {
 //	ActionValues("yes").Style(style.Green)
 //	ActionValues("no").Style(style.Red)
 func (a Action) Style(s string) Action {
-	return a.StyleF(func(_ string, _ style.Context) string {
+	styled := a.StyleF(func(_ string, _ style.Context) string {
 		return s
 	})
+	if a.spec != nil {
+		styled.spec = specStyled(a.spec, s)
+	}
+	return styled
 }
 
 // Style sets the style using a reference.
@@ -120,7 +184,7 @@ func (a Action) StyleR(s *string) Action {
 			return a.Style(*s)
 		}
 		return a
-	})
+	}).inherit(a)
 }
 
 // Style sets the style using a function.
@@ -134,7 +198,7 @@ func (a Action) StyleF(f func(s string, sc style.Context) string) Action {
 			invoked.rawValues[index].Style = f(v.Value, c)
 		}
 		return invoked.ToA()
-	})
+	}).inherit(a)
 }
 
 // Tag sets the tag.
@@ -158,7 +222,7 @@ func (a Action) TagF(f func(value string) string) Action {
 			invoked.rawValues[index].Tag = f(v.Value)
 		}
 		return invoked.ToA()
-	})
+	}).inherit(a)
 }
 
 // Chdir changes the current working directory to the named directory for the duration of invocation.
@@ -191,9 +255,13 @@ func (a Action) Suppress(expr ...string) Action {
 
 // MultiParts splits values of an Action by given dividers and completes each segment separately.
 func (a Action) MultiParts(dividers ...string) Action {
-	return ActionCallback(func(c Context) Action {
+	multiparts := ActionCallback(func(c Context) Action {
 		return a.Invoke(c).ToMultiPartsA(dividers...)
 	})
+	if a.spec != nil { // the macro is only meaningful along with the values it splits
+		multiparts = multiparts.withSpec(append(append([]string{}, a.spec...), macro("multiparts", dividers...))...)
+	}
+	return multiparts
 }
 
 // List wraps the Action in an ActionMultiParts with given divider.
@@ -229,6 +297,7 @@ func (a Action) Suffix(suffix string) Action {
 }
 
 // Timeout sets the maximum duration an Action may take to invoke.
+// The invocation is cancelled afterwards (nested callbacks stop early and processes started with Context.Command are killed).
 //
 //	carapace.ActionCallback(func(c carapace.Context) carapace.Action {
 //		time.Sleep(2*time.Second)
@@ -236,19 +305,19 @@ func (a Action) Suffix(suffix string) Action {
 //	}).Timeout(1*time.Second, carapace.ActionMessage("timeout exceeded"))
 func (a Action) Timeout(d time.Duration, alternative Action) Action {
 	return ActionCallback(func(c Context) Action {
-		currentChannel := make(chan string, 1)
+		c, cancel := c.withTimeout(d)
+		defer cancel()
 
-		var result InvokedAction
+		currentChannel := make(chan InvokedAction, 1)
 		go func() {
-			result = a.Invoke(c)
-			currentChannel <- ""
+			currentChannel <- a.Invoke(c)
 		}()
 
 		select {
-		case <-currentChannel:
-		case <-time.After(d):
+		case result := <-currentChannel:
+			return result.ToA()
+		case <-c.Done():
 			return alternative
 		}
-		return result.ToA()
 	})
 }
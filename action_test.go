@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/rsteube/carapace/internal/assert"
+	"github.com/rsteube/carapace/internal/cache"
 	"github.com/rsteube/carapace/internal/common"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
 	"github.com/rsteube/carapace/pkg/style"
 )
 
@@ -75,6 +78,103 @@ func TestCache(t *testing.T) {
 	assertNotEqual(t, a1, a3)
 }
 
+func TestSetCache(t *testing.T) {
+	c := pkgcache.Memory()
+	SetCache(c)
+	defer SetCache(nil)
+
+	a := ActionCallback(func(c Context) Action {
+		return ActionValues(time.Now().String())
+	}).Cache(time.Minute)
+
+	a1 := a.Invoke(Context{})
+	a2 := a.Invoke(Context{})
+	assertEqual(t, a1, a2)
+
+	if entries, err := c.List(); err != nil || len(entries) != 1 {
+		t.Errorf("expected one cache entry: %v %v", entries, err)
+	}
+}
+
+func TestCacheStale(t *testing.T) {
+	c := pkgcache.Memory()
+	SetCache(c)
+	defer SetCache(nil)
+
+	a := ActionCallback(func(c Context) Action {
+		return ActionValues(time.Now().String())
+	}).CacheStale(10*time.Millisecond, time.Minute)
+
+	a1 := a.Invoke(Context{})
+	time.Sleep(15 * time.Millisecond)
+	a2 := a.Invoke(Context{})
+	assertEqual(t, a1, a2) // stale value returned immediately
+
+	entries, _ := c.List()
+	for i := 0; i < 100; i++ { // wait for background refresh
+		if refreshed, _ := c.List(); len(refreshed) == 1 && refreshed[0].ModTime.After(entries[0].ModTime) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	a3 := a.Invoke(Context{})
+	assertNotEqual(t, a1, a3)
+}
+
+func TestCacheLimits(t *testing.T) {
+	c := pkgcache.Memory()
+	SetCache(c)
+	defer SetCache(nil)
+	defer SetCacheLimits(0, 0)
+	defer func(interval time.Duration) { cache.EvictInterval = interval }(cache.EvictInterval)
+	cache.EvictInterval = 0
+
+	cached := func(s string) Action {
+		return ActionCallback(func(c Context) Action {
+			return ActionValues(s)
+		}).Cache(time.Minute, pkgcache.String(s))
+	}
+
+	cached("first").Invoke(Context{})
+	entries, _ := c.List()
+	SetCacheLimits(entries[0].Size*5/2, 0) // room for two entries
+
+	time.Sleep(time.Millisecond)
+	cached("second").Invoke(Context{})
+	time.Sleep(time.Millisecond)
+	cached("first").Invoke(Context{}) // access first so second is least recently used
+	time.Sleep(time.Millisecond)
+	cached("third").Invoke(Context{})
+
+	entries, _ = c.List()
+	if len(entries) != 2 {
+		t.Fatalf("expected two entries: %#v", entries)
+	}
+	for _, entry := range entries {
+		if content, _, _ := c.Load(entry.Key); strings.Contains(string(content), `"second"`) {
+			t.Error("expected least recently used entry to be evicted")
+		}
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c := pkgcache.Memory()
+	SetCache(c)
+	defer SetCache(nil)
+
+	os.Setenv("CARAPACE_CACHE_DISABLED", "1")
+	defer os.Unsetenv("CARAPACE_CACHE_DISABLED")
+
+	ActionCallback(func(c Context) Action {
+		return ActionValues("disabled")
+	}).Cache(time.Minute).Invoke(Context{})
+
+	if entries, _ := c.List(); len(entries) != 0 {
+		t.Errorf("expected no cache entries: %#v", entries)
+	}
+}
+
 func TestSkipCache(t *testing.T) {
 	a := ActionCallback(func(c Context) Action {
 		return ActionValues().Invoke(c).Merge(
@@ -237,3 +337,47 @@ func TestActionExecCommand(t *testing.T) {
 		ActionExecCommand("head", "-n1", "go.mod")(func(output []byte) Action { return ActionValues(string(output)) }).Invoke(Context{}),
 	)
 }
+
+func TestTimeout(t *testing.T) {
+	assertEqual(t,
+		ActionValues("within").Invoke(Context{}),
+		ActionValues("within").Timeout(time.Second, ActionMessage("timeout exceeded")).Invoke(Context{}),
+	)
+
+	cancelled := make(chan error, 1)
+	start := time.Now()
+	assertEqual(t,
+		ActionMessage("timeout exceeded").Invoke(Context{}),
+		ActionCallback(func(c Context) Action {
+			<-c.Done()
+			cancelled <- c.Err()
+			return ActionValues("cancelled")
+		}).Timeout(100*time.Millisecond, ActionMessage("timeout exceeded")).Invoke(Context{}),
+	)
+	if time.Since(start) > time.Second {
+		t.Error("timeout should return early")
+	}
+	if err := <-cancelled; err == nil {
+		t.Error("context should be cancelled")
+	}
+}
+
+func TestTimeoutExecCommand(t *testing.T) {
+	c, cancel := Context{}.withTimeout(100 * time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	var execErr error
+	ActionExecCommandE("sleep", "5")(func(output []byte, err error) Action {
+		execErr = err
+		return ActionValues()
+	}).Invoke(c)
+	if execErr == nil || time.Since(start) > 2*time.Second {
+		t.Error("process should be killed on timeout")
+	}
+
+	assertEqual(t,
+		ActionMessage("context deadline exceeded").Invoke(Context{}),
+		ActionCallback(func(c Context) Action { return ActionValues("nested") }).Invoke(c),
+	)
+}
@@ -1,7 +1,5 @@
 package carapace
 
-import "sync"
-
 type (
 	batch        []Action
 	invokedBatch []InvokedAction
@@ -13,18 +11,28 @@ func Batch(actions ...Action) batch {
 }
 
 // Invoke invokes contained Actions of the batch using goroutines.
+// Actions not yet finished when the invocation is cancelled are replaced by an ActionMessage.
 func (b batch) Invoke(c Context) invokedBatch {
-	invokedActions := make([]InvokedAction, len(b))
-	functions := make([]func(), len(b))
-
+	results := make([]chan InvokedAction, len(b))
 	for index, action := range b {
-		localIndex := index
-		localAction := action
-		functions[index] = func() {
-			invokedActions[localIndex] = localAction.Invoke(c)
+		results[index] = make(chan InvokedAction, 1)
+		go func(result chan<- InvokedAction, a Action) {
+			result <- a.Invoke(c)
+		}(results[index], action)
+	}
+
+	invokedActions := make([]InvokedAction, len(b))
+	for index, result := range results {
+		select {
+		case invokedActions[index] = <-result:
+		case <-c.Done():
+			select {
+			case invokedActions[index] = <-result: // prefer already finished ones
+			default:
+				invokedActions[index] = ActionMessage(c.Err().Error()).Invoke(Context{})
+			}
 		}
 	}
-	parallelize(functions...)
 	return invokedActions
 }
 
@@ -50,18 +58,3 @@ func (b invokedBatch) Merge() InvokedAction {
 		return b[0].Merge(b[1:]...)
 	}
 }
-
-// Parallelize parallelizes the function calls (https://stackoverflow.com/a/44402936)
-func parallelize(functions ...func()) {
-	var waitGroup sync.WaitGroup
-	waitGroup.Add(len(functions))
-
-	defer waitGroup.Wait()
-
-	for _, function := range functions {
-		go func(copy func()) {
-			defer waitGroup.Done()
-			copy()
-		}(function)
-	}
-}
@@ -2,6 +2,7 @@ package carapace
 
 import (
 	"testing"
+	"time"
 
 	"github.com/rsteube/carapace/internal/common"
 )
@@ -59,3 +60,19 @@ func TestBatchToA(t *testing.T) {
 	actual := b.ToA().Invoke(Context{})
 	assertEqual(t, expected, actual)
 }
+
+func TestBatchCancelled(t *testing.T) {
+	c, cancel := Context{}.withTimeout(100 * time.Millisecond)
+	defer cancel()
+
+	b := Batch(
+		ActionValues("A", "B"),
+		ActionCallback(func(c Context) Action {
+			time.Sleep(time.Second)
+			return ActionValues("C")
+		}),
+	)
+	expected := ActionValues("A", "B").Invoke(Context{}).Merge(ActionMessage("context deadline exceeded").Invoke(Context{}))
+	actual := b.Invoke(c).Merge()
+	assertEqual(t, expected, actual)
+}
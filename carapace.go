@@ -2,9 +2,15 @@
 package carapace
 
 import (
+	"fmt"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/rsteube/carapace/internal/cache"
+	"github.com/rsteube/carapace/internal/pflagfork"
 	"github.com/rsteube/carapace/internal/shell"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -14,9 +20,14 @@ type Carapace struct {
 	cmd *cobra.Command
 }
 
+// genMutex serializes adding the completion command as Gen might be called concurrently.
+var genMutex sync.Mutex
+
 // Gen initialized Carapace for given command.
 func Gen(cmd *cobra.Command) *Carapace {
+	genMutex.Lock()
 	addCompletionCommand(cmd)
+	genMutex.Unlock()
 	storage.bridge(cmd)
 
 	return &Carapace{
@@ -26,60 +37,161 @@ func Gen(cmd *cobra.Command) *Carapace {
 
 // PreRun sets a function to be run before completion.
 func (c Carapace) PreRun(f func(cmd *cobra.Command, args []string)) {
-	if entry := storage.get(c.cmd); entry.prerun != nil {
-		_f := entry.prerun
-		entry.prerun = func(cmd *cobra.Command, args []string) {
-			// TODO yuck - probably best to append to a slice in storage
-			_f(cmd, args)
-			f(cmd, args)
-
+	storage.update(c.cmd, func(e *entry) {
+		if e.prerun != nil {
+			_f := e.prerun
+			e.prerun = func(cmd *cobra.Command, args []string) {
+				// TODO yuck - probably best to append to a slice in storage
+				_f(cmd, args)
+				f(cmd, args)
+
+			}
+		} else {
+			e.prerun = f
 		}
-	} else {
-		entry.prerun = f
-	}
+	})
 }
 
 // PreInvoke sets a function to alter actions before they are invoked.
 func (c Carapace) PreInvoke(f func(cmd *cobra.Command, flag *pflag.Flag, action Action) Action) {
-	if entry := storage.get(c.cmd); entry.preinvoke != nil {
-		_f := entry.preinvoke
-		entry.preinvoke = func(cmd *cobra.Command, flag *pflag.Flag, action Action) Action {
-			return f(cmd, flag, _f(cmd, flag, action))
+	storage.update(c.cmd, func(e *entry) {
+		if e.preinvoke != nil {
+			_f := e.preinvoke
+			e.preinvoke = func(cmd *cobra.Command, flag *pflag.Flag, action Action) Action {
+				return f(cmd, flag, _f(cmd, flag, action))
+			}
+		} else {
+			e.preinvoke = f
 		}
-	} else {
-		entry.preinvoke = f
-	}
+	})
 }
 
 // PositionalCompletion defines completion for positional arguments using a list of Actions.
 func (c Carapace) PositionalCompletion(action ...Action) {
-	storage.get(c.cmd).positional = action
+	storage.update(c.cmd, func(e *entry) { e.positional = action })
 }
 
 // PositionalAnyCompletion defines completion for any positional arguments not already defined.
 func (c Carapace) PositionalAnyCompletion(action Action) {
-	storage.get(c.cmd).positionalAny = action
+	storage.update(c.cmd, func(e *entry) { e.positionalAny = &action })
 }
 
 // DashCompletion defines completion for positional arguments after dash (`--`) using a list of Actions.
 func (c Carapace) DashCompletion(action ...Action) {
-	storage.get(c.cmd).dash = action
+	storage.update(c.cmd, func(e *entry) { e.dash = action })
 }
 
 // DashAnyCompletion defines completion for any positional arguments after dash (`--`) not already defined.
 func (c Carapace) DashAnyCompletion(action Action) {
-	storage.get(c.cmd).dashAny = action
+	storage.update(c.cmd, func(e *entry) { e.dashAny = &action })
 }
 
 // FlagCompletion defines completion for flags using a map consisting of name and Action.
 func (c Carapace) FlagCompletion(actions ActionMap) {
-	if e := storage.get(c.cmd); e.flag == nil {
-		e.flag = actions
-	} else {
+	storage.update(c.cmd, func(e *entry) {
+		flag := make(ActionMap, len(e.flag)+len(actions))
+		for name, action := range e.flag {
+			flag[name] = action
+		}
 		for name, action := range actions {
-			e.flag[name] = action
+			flag[name] = action
 		}
+		e.flag = flag
+	})
+}
+
+// FlagArgsCompletion defines completion for each argument of flags consuming multiple arguments (see MarkFlagNargs).
+// The last Action is used for any further argument.
+//
+//	carapace.Gen(cmd).FlagArgsCompletion(map[string][]carapace.Action{
+//		"point": {carapace.ActionValues("x"), carapace.ActionValues("y"), carapace.ActionValues("z")},
+//	})
+func (c Carapace) FlagArgsCompletion(actions map[string][]Action) {
+	flagActions := make(ActionMap, len(actions))
+	for name, a := range actions {
+		flagActions[name] = actionFlagArgs(a...)
+	}
+	c.FlagCompletion(flagActions)
+}
+
+// MarkFlagNargs sets the minimum and maximum amount of arguments consumed by a flag (maximum is `-1` if unlimited).
+//
+//	carapace.Gen(cmd).MarkFlagNargs("point", 3, 3)
+func (c Carapace) MarkFlagNargs(name string, min, max int) error {
+	flag := c.cmd.Flags().Lookup(name)
+	if flag == nil { // persistent flags are only merged into Flags on execution
+		flag = c.cmd.PersistentFlags().Lookup(name)
+	}
+	if flag == nil {
+		return fmt.Errorf("unknown flag: %v", name)
+	}
+	return (pflagfork.Flag{Flag: flag}).SetNargs(min, max)
+}
+
+// MarkFlagNegatable allows a bool flag to be set to false with `--no-<name>` (described by given usage).
+//
+//	carapace.Gen(cmd).MarkFlagNegatable("color", "disable colors")
+func (c Carapace) MarkFlagNegatable(name, usage string) error {
+	flags := c.cmd.Flags()
+	flag := flags.Lookup(name)
+	if flag == nil { // persistent flags are only merged into Flags on execution
+		flags = c.cmd.PersistentFlags()
+		flag = flags.Lookup(name)
+	}
+	switch {
+	case flag == nil:
+		return fmt.Errorf("unknown flag: %v", name)
+	case flag.Value.Type() != "bool":
+		return fmt.Errorf("flag is not a bool: %v", name)
 	}
+	return flags.SetAnnotation(name, pflagfork.AnnotationNegatable, []string{usage})
+}
+
+// FlagAbbreviation allows unambiguous prefixes of long flags (e.g. `--verb` for `--verbose`) like GNU getopt_long.
+// It is inherited by subcommands.
+func (c Carapace) FlagAbbreviation() {
+	storage.update(c.cmd, func(e *entry) { e.abbreviate = true })
+}
+
+// Registration contains the completion configured for a command.
+type Registration struct {
+	Flag          ActionMap
+	Positional    []Action
+	PositionalAny *Action // nil if not configured
+	Dash          []Action
+	DashAny       *Action // nil if not configured
+	PreRun        bool
+	PreInvoke     bool
+}
+
+// Registered returns a copy of the completion configured for given command.
+// Inherited completion (e.g. for persistent flags) is not included.
+//
+//	for name := range carapace.Registered(cmd).Flag {
+//		fmt.Println(name)
+//	}
+func Registered(cmd *cobra.Command) Registration {
+	e := storage.get(cmd)
+
+	r := Registration{
+		Flag:       make(ActionMap, len(e.flag)),
+		Positional: append([]Action{}, e.positional...),
+		Dash:       append([]Action{}, e.dash...),
+		PreRun:     e.prerun != nil,
+		PreInvoke:  e.preinvoke != nil,
+	}
+	for name, action := range e.flag {
+		r.Flag[name] = action
+	}
+	if e.positionalAny != nil {
+		a := *e.positionalAny
+		r.PositionalAny = &a
+	}
+	if e.dashAny != nil {
+		a := *e.dashAny
+		r.DashAny = &a
+	}
+	return r
 }
 
 // Standalone prevents cobra defaults interfering with standalone mode (e.g. implicit help command).
@@ -106,13 +218,28 @@ func IsCallback() bool {
 	return len(os.Args) > 1 && os.Args[1] == "_carapace"
 }
 
-// Test verifies the configuration (e.g. flag name exists)
+// SetCache sets the backend used by Action.Cache (nil restores the default file cache).
+//
+//	carapace.SetCache(cache.SingleFile("/tmp/example.cache"))
+func SetCache(c pkgcache.Cache) {
+	cache.Set(c)
+}
+
+// SetCacheLimits sets the maximum total size (in bytes) and age of cache entries (0 disables the limit).
+// Exceeding entries are evicted when writing (at most once per hour), least recently used first.
+//
+//	carapace.SetCacheLimits(10<<20, 7*24*time.Hour)
+func SetCacheLimits(size int64, age time.Duration) {
+	cache.SetLimits(size, age)
+}
+
+// Test verifies the configuration (see Lint)
 //
 //	func TestCarapace(t *testing.T) {
 //	    carapace.Test(t)
 //	}
 func Test(t interface{ Error(args ...interface{}) }) {
-	for _, e := range storage.check() {
-		t.Error(e)
+	for _, issue := range Lint() {
+		t.Error(issue.String())
 	}
 }
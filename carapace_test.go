@@ -1,6 +1,7 @@
 package carapace
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"strings"
@@ -219,6 +220,108 @@ func TestSnippet(t *testing.T) {
 	}
 }
 
+func taggedCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "tagged", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().Bool("flag", false, "flag usage")
+	cmd.AddGroup(&cobra.Group{ID: "main", Title: "Main Commands"})
+	cmd.AddCommand(
+		&cobra.Command{Use: "sub1", Short: "first", GroupID: "main", Run: func(*cobra.Command, []string) {}},
+		&cobra.Command{Use: "sub2", Run: func(*cobra.Command, []string) {}},
+	)
+	return cmd
+}
+
+func TestTaggedFormat(t *testing.T) {
+	if a, _ := complete(taggedCmd(), []string{"fish", "tagged", ""}); a != "sub1\tmain commands: first\nsub2\tother commands" {
+		t.Errorf("fish should prefix descriptions with tag: %#v", a)
+	}
+
+	if a, _ := complete(taggedCmd(), []string{"powershell", "tagged", ""}); !strings.Contains(a, `"ToolTip":"main commands: first"`) ||
+		!strings.Contains(a, `"ToolTip":"other commands"`) {
+		t.Errorf("powershell should encode tag in tooltip: %v", a)
+	}
+
+	if a, _ := complete(taggedCmd(), []string{"zsh", "tagged", ""}); !strings.Contains(a, "main commands\003") ||
+		!strings.Contains(a, "\002other commands\003") {
+		t.Errorf("zsh should group values by tag: %#v", a)
+	}
+
+	if a, _ := complete(taggedCmd(), []string{"fish", "tagged", "--"}); a != "--flag\tflag usage" {
+		t.Errorf("fish should not prefix descriptions for a single tag: %#v", a)
+	}
+}
+
+func TestClinkWords(t *testing.T) {
+	cmd := &cobra.Command{Use: "clink", Run: func(*cobra.Command, []string) {}}
+	Gen(cmd).PositionalCompletion(ActionValues("foo&calc", "%PATH%", "other"))
+
+	os.Setenv("CARAPACE_CLINK_WORDS", "clink\nfoo&")
+	defer os.Unsetenv("CARAPACE_CLINK_WORDS")
+
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"_carapace", "clink"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if expected := "foo&calc\tfoo&calc\t\tfalse\n"; stdout.String() != expected {
+		t.Errorf("expected %#v: %#v", expected, stdout.String())
+	}
+}
+
+func TestNushellMessages(t *testing.T) {
+	cmd := &cobra.Command{Use: "nushell", Run: func(*cobra.Command, []string) {}}
+	Gen(cmd).PositionalCompletion(ActionMessage("some error"))
+
+	if a, _ := complete(cmd, []string{"nushell", "nushell", ""}); !strings.Contains(a, `{"value":"ERR","description":"some error","style":{"fg":"red","attr":"b"}}`) {
+		t.Errorf("nushell should integrate messages: %v", a)
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	quotingCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "quoting", Run: func(*cobra.Command, []string) {}}
+		Gen(cmd).PositionalCompletion(ActionValues("it's", `a\"b`, "~/My Documents/").NoSpace('/'))
+		return cmd
+	}
+
+	tests := []struct {
+		shell    string
+		quote    string
+		value    string
+		expected string
+	}{
+		{"bash", "", "it", `it\'s `},
+		{"bash", "", "a", `a\\\"b `},
+		{"bash", "", "~", `~/My\ Documents/`},
+		{"bash", `'`, "it", `it'\''s' `},
+		{"bash", `"`, "a", `a\\\"b" `},
+		{"oil", `'`, "it", `it'\''s'`},
+		{"tcsh", `'`, "it", `it'\''s'`},
+		{"powershell", "", "it", `"CompletionText":"'it''s' "`},
+		{"powershell", `"`, "it", `"CompletionText":"\"it's\" "`},
+		{"nushell", "", "it", `{"value":"\"it's\" "`},
+		{"nushell", `"`, "a", `{"value":"\"a\\\\\\\"b\" "`},
+		{"xonsh", "", "a", `"Value":"'a\\\\\"b' "`},
+		{"xonsh", `"`, "it", `"Value":"\"it's\" "`},
+		{"ion", `"`, "a", `"Value":"\"a\\\\\\\"b\" "`},
+		{"murex", `"`, "a", `"\"a\\\\\\\"b\" ":""`},
+	}
+	for _, test := range tests {
+		os.Setenv("CARAPACE_QUOTE", test.quote)
+		if a, _ := complete(quotingCmd(), []string{test.shell, "quoting", test.value}); !strings.Contains(a, test.expected) {
+			t.Errorf("expected %#v for %v [%v, quote: %v]: %#v", test.expected, test.value, test.shell, test.quote, a)
+		}
+	}
+	os.Unsetenv("CARAPACE_QUOTE")
+
+	os.Setenv("COMMAND_LINE", `quoting 'it`)
+	defer os.Unsetenv("COMMAND_LINE")
+	if a, _ := complete(quotingCmd(), []string{"tcsh", "quoting", "it"}); a != `it'\''s'` {
+		t.Errorf("expected quote state to be derived from COMMAND_LINE [tcsh]: %#v", a)
+	}
+}
+
 func TestTest(t *testing.T) {
 	Test(t)
 }
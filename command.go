@@ -5,7 +5,13 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
+	"github.com/rsteube/carapace/internal/cache"
+	"github.com/rsteube/carapace/internal/env"
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/rsteube/carapace/internal/uid"
 	"github.com/rsteube/carapace/pkg/style"
 	"github.com/spf13/cobra"
@@ -23,20 +29,7 @@ func addCompletionCommand(cmd *cobra.Command) {
 		Hidden: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			LOG.Printf("%#v", os.Args)
-
-			if len(args) > 2 && strings.HasPrefix(args[2], "_") {
-				cmd.Hidden = false
-			}
-
-			if !cmd.HasParent() {
-				panic("missing parent command") // this should never happen
-			}
-
-			if s, err := complete(cmd.Parent(), args); err != nil {
-				fmt.Fprintln(io.MultiWriter(cmd.OutOrStderr(), LOG.Writer()), err.Error())
-			} else {
-				fmt.Fprintln(io.MultiWriter(cmd.OutOrStdout(), LOG.Writer()), s)
-			}
+			runCallback(cmd, args, cmd.OutOrStdout(), cmd.OutOrStderr())
 		},
 		FParseErrWhitelist: cobra.FParseErrWhitelist{
 			UnknownFlags: true,
@@ -50,16 +43,20 @@ func addCompletionCommand(cmd *cobra.Command) {
 		ActionStyledValues(
 			"bash", "#d35673",
 			"bash-ble", "#c2039a",
+			"clink", "#2b6fb6",
+			"cobra", style.Default,
 			"elvish", "#ffd6c9",
 			"export", style.Default,
 			"fish", "#7ea8fc",
 			"ion", "#0e5d6d",
+			"murex", "#b3344e",
 			"nushell", "#29d866",
 			"oil", "#373a36",
 			"powershell", "#e8a16f",
 			"spec", style.Default,
 			"tcsh", "#412f09",
 			"xonsh", "#a8ffa9",
+			"yash", "#6cb2d8",
 			"zsh", "#efda53",
 		),
 		ActionValues(cmd.Root().Name()),
@@ -78,6 +75,25 @@ func addCompletionCommand(cmd *cobra.Command) {
 		}),
 	)
 
+	serveCmd := &cobra.Command{
+		Use:   "serve [socket]",
+		Short: "start completion server",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			socket := server.Socket()
+			if len(args) > 0 {
+				socket = args[0]
+			}
+			if err := serve(carapaceCmd, socket); err != nil {
+				fmt.Fprintln(io.MultiWriter(cmd.ErrOrStderr(), LOG.Writer()), err.Error())
+			}
+		},
+	}
+	carapaceCmd.AddCommand(serveCmd)
+	Carapace{serveCmd}.PositionalCompletion(
+		ActionFiles(),
+	)
+
 	styleCmd := &cobra.Command{
 		Use:  "style",
 		Args: cobra.ExactArgs(1),
@@ -104,4 +120,226 @@ func addCompletionCommand(cmd *cobra.Command) {
 	Carapace{styleSetCmd}.PositionalAnyCompletion(
 		ActionStyleConfig(),
 	)
+
+	addCacheCommand(carapaceCmd)
+}
+
+func addCacheCommand(carapaceCmd *cobra.Command) {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "manage the completion cache",
+		Args:  cobra.ExactArgs(1),
+		Run:   func(cmd *cobra.Command, args []string) {},
+	}
+	carapaceCmd.AddCommand(cacheCmd)
+
+	cacheListCmd := &cobra.Command{
+		Use:   "list",
+		Short: "list cache entries",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			infos, err := cache.List()
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				return
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CALLER\tKEY\tAGE\tSIZE")
+			for _, info := range infos {
+				fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", info.Caller, info.Key, time.Since(info.ModTime).Round(time.Second), formatSize(info.Size))
+			}
+			w.Flush()
+		},
+	}
+	cacheCmd.AddCommand(cacheListCmd)
+
+	cacheClearCmd := &cobra.Command{
+		Use:   "clear [filter]...",
+		Short: "remove cache entries whose key or caller contains any of given filters",
+		Run: func(cmd *cobra.Command, args []string) {
+			infos, err := cache.List()
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				return
+			}
+
+			for _, info := range infos {
+				if matchesCacheFilter(info, args) {
+					if err := cache.Delete(info.Key); err != nil {
+						fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+					}
+				}
+			}
+		},
+	}
+	cacheCmd.AddCommand(cacheClearCmd)
+	Carapace{cacheClearCmd}.PositionalAnyCompletion(
+		ActionCallback(func(c Context) Action {
+			infos, err := cache.List()
+			if err != nil {
+				return ActionMessage(err.Error())
+			}
+
+			callers := make(map[string]int)
+			keys := make([]string, 0)
+			for _, info := range infos {
+				callers[info.Caller]++
+				keys = append(keys, info.Key, info.Caller)
+			}
+
+			vals := make([]string, 0)
+			for caller, count := range callers {
+				if caller != "" {
+					vals = append(vals, caller, fmt.Sprintf("%v entries", count))
+				}
+			}
+
+			return Batch(
+				ActionValuesDescribed(vals...).Tag("callers"),
+				ActionValuesDescribed(keys...).Tag("keys"),
+			).ToA().Invoke(c).Filter(c.Args).ToA()
+		}),
+	)
+
+	cachePruneCmd := &cobra.Command{
+		Use:   "prune <duration>",
+		Short: "remove cache entries older than given duration",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			d, err := time.ParseDuration(args[0])
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				return
+			}
+
+			infos, err := cache.List()
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				return
+			}
+
+			for _, info := range infos {
+				if time.Since(info.ModTime) > d {
+					if err := cache.Delete(info.Key); err != nil {
+						fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+					}
+				}
+			}
+		},
+	}
+	cacheCmd.AddCommand(cachePruneCmd)
+	Carapace{cachePruneCmd}.PositionalCompletion(
+		ActionValuesDescribed(
+			"1h", "one hour",
+			"24h", "one day",
+			"168h", "one week",
+			"720h", "thirty days",
+		),
+	)
+
+	cacheStatsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "show cache usage",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			infos, err := cache.List()
+			if err != nil {
+				fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+				return
+			}
+
+			var size int64
+			var oldest, newest time.Time
+			callers := make(map[string]bool)
+			for _, info := range infos {
+				size += info.Size
+				callers[info.Caller] = true
+				if oldest.IsZero() || info.ModTime.Before(oldest) {
+					oldest = info.ModTime
+				}
+				if info.ModTime.After(newest) {
+					newest = info.ModTime
+				}
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 1, ' ', 0)
+			fmt.Fprintf(w, "entries:\t%v\n", len(infos))
+			fmt.Fprintf(w, "callers:\t%v\n", len(callers))
+			fmt.Fprintf(w, "size:\t%v\n", formatSize(size))
+			if len(infos) > 0 {
+				fmt.Fprintf(w, "oldest:\t%v\n", time.Since(oldest).Round(time.Second))
+				fmt.Fprintf(w, "newest:\t%v\n", time.Since(newest).Round(time.Second))
+			}
+			w.Flush()
+		},
+	}
+	cacheCmd.AddCommand(cacheStatsCmd)
+}
+
+// matchesCacheFilter returns true if the key or caller of given entry contains any of the filters (or no filters are given).
+func matchesCacheFilter(info cache.Info, filters []string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, filter := range filters {
+		if strings.Contains(info.Key, filter) || strings.Contains(info.Caller, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSize formats given bytes in a human readable form.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%vB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// callbackArgs contains the arguments of the current `_carapace` invocation (nil otherwise).
+var callbackArgs struct {
+	sync.RWMutex
+	args []string
+}
+
+func currentCallbackArgs() []string {
+	callbackArgs.RLock()
+	defer callbackArgs.RUnlock()
+	return callbackArgs.args
+}
+
+func setCallbackArgs(args []string) {
+	callbackArgs.Lock()
+	defer callbackArgs.Unlock()
+	callbackArgs.args = args
+}
+
+// runCallback writes the result of a `_carapace` invocation with given args.
+func runCallback(cmd *cobra.Command, args []string, stdout, stderr io.Writer) {
+	if len(args) == 1 && args[0] == "clink" {
+		args = append(args, env.ClinkWords()...) // passed by environment as cmd.exe would interpret metacharacters
+	}
+
+	setCallbackArgs(args)
+	defer setCallbackArgs(nil)
+
+	cmd.Hidden = !(len(args) > 2 && strings.HasPrefix(args[2], "_"))
+
+	if !cmd.HasParent() {
+		panic("missing parent command") // this should never happen
+	}
+
+	if s, err := complete(cmd.Parent(), args); err != nil {
+		fmt.Fprintln(io.MultiWriter(stderr, LOG.Writer()), err.Error())
+	} else {
+		fmt.Fprintln(io.MultiWriter(stdout, LOG.Writer()), s)
+	}
 }
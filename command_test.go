@@ -0,0 +1,76 @@
+package carapace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rsteube/carapace/internal/cache"
+	pkgcache "github.com/rsteube/carapace/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+func TestCacheCommand(t *testing.T) {
+	SetCache(pkgcache.Memory())
+	defer SetCache(nil)
+
+	ActionCallback(func(c Context) Action {
+		return ActionValues("one")
+	}).Cache(time.Minute, pkgcache.String("one")).Invoke(Context{})
+	ActionCallback(func(c Context) Action {
+		return ActionValues("two")
+	}).Cache(time.Minute, pkgcache.String("two")).Invoke(Context{})
+
+	cmd := &cobra.Command{Use: "cache"}
+	Gen(cmd)
+
+	execute := func(args ...string) string {
+		resetFlags(cmd)
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetArgs(append([]string{"_carapace", "cache"}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err.Error())
+		}
+		return stdout.String()
+	}
+
+	if output := execute("list"); strings.Count(output, "command_test.go:") != 2 {
+		t.Errorf("expected two entries:\n%v", output)
+	}
+
+	if output := execute("stats"); !strings.Contains(output, "entries: 2") || !strings.Contains(output, "callers: 2") {
+		t.Errorf("unexpected stats:\n%v", output)
+	}
+
+	infos, _ := cache.List()
+	execute("clear", infos[0].Caller)
+	if output := execute("list"); strings.Count(output, "command_test.go:") != 1 || strings.Contains(output, infos[0].Caller+" ") {
+		t.Errorf("expected entry to be cleared:\n%v", output)
+	}
+
+	execute("prune", "1h")
+	if output := execute("stats"); !strings.Contains(output, "entries: 1") {
+		t.Errorf("expected recent entry to survive prune:\n%v", output)
+	}
+
+	execute("clear")
+	if output := execute("stats"); !strings.Contains(output, "entries: 0") {
+		t.Errorf("expected all entries to be cleared:\n%v", output)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for size, expected := range map[int64]string{
+		0:       "0B",
+		1023:    "1023B",
+		1024:    "1.0KiB",
+		1536:    "1.5KiB",
+		1 << 20: "1.0MiB",
+	} {
+		if actual := formatSize(size); actual != expected {
+			t.Errorf("expected %v for %v, got %v", expected, size, actual)
+		}
+	}
+}
@@ -2,34 +2,43 @@ package carapace
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
+// activeHelpMarker is the prefix cobra uses for ActiveHelp.
+var activeHelpMarker = cobra.AppendActiveHelp(nil, "")[0]
+
 func registerValidArgsFunction(cmd *cobra.Command) {
 	if cmd.ValidArgsFunction == nil {
 		cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			action := storage.getPositional(cmd, len(args)).Invoke(Context{Args: args, Value: toComplete})
-			return cobraValuesFor(action), cobraDirectiveFor(action)
+			action := storage.getPositional(cmd, len(args)).Invoke(Context{Args: args, Value: toComplete, flagSet: cmd.Flags()})
+			return cobraValuesFor(cmd, action, cmd.Use), cobraDirectiveFor(action)
 		}
+		storage.markBridged(cmd.ValidArgsFunction)
 	}
 }
 
 func registerFlagCompletion(cmd *cobra.Command) {
 	cmd.Flags().VisitAll(func(f *pflag.Flag) {
-		err := cmd.RegisterFlagCompletionFunc(f.Name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		completionFunc := func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			a := storage.getFlag(cmd, f.Name)
-			action := a.Invoke(Context{Args: args, Value: toComplete})
-			return cobraValuesFor(action), cobraDirectiveFor(action)
-		})
-		if err != nil {
+			action := a.Invoke(Context{Args: args, Value: toComplete, flagSet: cmd.Flags()})
+			return cobraValuesFor(cmd, action, f.Usage), cobraDirectiveFor(action)
+		}
+		if err := cmd.RegisterFlagCompletionFunc(f.Name, completionFunc); err != nil {
 			LOG.Printf("failed to register flag completion func: %v", err.Error())
+			return
 		}
+		storage.markBridged(completionFunc)
 	})
 }
 
-func cobraValuesFor(action InvokedAction) []string {
+// cobraValuesFor translates the values of given action for cobra.
+// Usage is only passed as ActiveHelp if it was explicitly set (differs from the default usage of the flag or command).
+func cobraValuesFor(cmd *cobra.Command, action InvokedAction, defaultUsage string) []string {
 	result := make([]string, len(action.rawValues))
 	for index, r := range action.rawValues {
 		if r.Description != "" {
@@ -38,6 +47,15 @@ func cobraValuesFor(action InvokedAction) []string {
 			result[index] = r.Value
 		}
 	}
+
+	if cobra.GetActiveHelpConfig(cmd) != "0" { // usage and messages are passed as ActiveHelp
+		if action.meta.Usage != "" && action.meta.Usage != defaultUsage {
+			result = cobra.AppendActiveHelp(result, action.meta.Usage)
+		}
+		for _, message := range action.meta.Messages.Get() {
+			result = cobra.AppendActiveHelp(result, message)
+		}
+	}
 	return result
 }
 
@@ -51,3 +69,98 @@ func cobraDirectiveFor(action InvokedAction) cobra.ShellCompDirective {
 	}
 	return directive
 }
+
+// cobraPositional returns an Action for `ValidArgs` and `ValidArgsFunction` of given command (if any).
+// Bridged functions are skipped as these would invoke the Action again (endless recursion).
+func cobraPositional(cmd *cobra.Command, index int) Action {
+	validArgsFunction := cmd.ValidArgsFunction
+	if storage.isBridged(validArgsFunction) {
+		validArgsFunction = nil
+	}
+	if (index > 0 || len(cmd.ValidArgs) == 0) && validArgsFunction == nil {
+		return Action{}
+	}
+
+	return ActionCallback(func(c Context) Action {
+		batch := Batch()
+		if index == 0 && len(cmd.ValidArgs) > 0 { // like cobra ValidArgs are only used for the first argument
+			batch = append(batch, actionCobra(cmd.ValidArgs, cobra.ShellCompDirectiveNoFileComp))
+		}
+		if validArgsFunction != nil {
+			batch = append(batch, actionCobraFunc(cmd, validArgsFunction, c))
+		}
+		return batch.ToA()
+	})
+}
+
+// cobraFlag returns an Action for the flag completion function registered in cobra (if any).
+// Bridged functions are skipped as these would invoke the Action again (endless recursion).
+func cobraFlag(cmd *cobra.Command, name string) Action {
+	f, ok := cmd.GetFlagCompletionFunc(name)
+	if !ok || storage.isBridged(f) {
+		return Action{}
+	}
+
+	return ActionCallback(func(c Context) Action {
+		return actionCobraFunc(cmd, f, c)
+	})
+}
+
+func actionCobraFunc(cmd *cobra.Command, f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective), c Context) Action {
+	return actionCobra(f(cmd, c.Args, c.Value))
+}
+
+// actionCobra translates values and directive of a cobra completion function.
+// ActiveHelp is passed as usage (or message in case of an error).
+//
+//	value\tdescription
+func actionCobra(values []string, directive cobra.ShellCompDirective) Action {
+	activeHelp := make([]string, 0)
+	filtered := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.HasPrefix(value, activeHelpMarker) {
+			activeHelp = append(activeHelp, strings.TrimPrefix(value, activeHelpMarker))
+		} else {
+			filtered = append(filtered, value)
+		}
+	}
+
+	if directive&cobra.ShellCompDirectiveError != 0 {
+		if len(activeHelp) > 0 {
+			return ActionMessage(strings.Join(activeHelp, "; "))
+		}
+		return ActionValues()
+	}
+	return actionCobraValues(filtered, directive).Usage(strings.Join(activeHelp, "; "))
+}
+
+func actionCobraValues(values []string, directive cobra.ShellCompDirective) Action {
+	switch {
+	case directive&cobra.ShellCompDirectiveFilterFileExt != 0:
+		extensions := make([]string, 0, len(values))
+		for _, extension := range values {
+			extensions = append(extensions, "."+extension)
+		}
+		return ActionFiles(extensions...)
+	case directive&cobra.ShellCompDirectiveFilterDirs != 0:
+		if len(values) > 0 {
+			return ActionDirectories().Chdir(values[0])
+		}
+		return ActionDirectories()
+	}
+
+	vals := make([]string, 0, len(values)*2)
+	for _, value := range values {
+		splitted := strings.SplitN(value, "\t", 2)
+		vals = append(vals, splitted[0], strings.Join(splitted[1:], ""))
+	}
+
+	a := ActionValuesDescribed(vals...)
+	if len(vals) == 0 && directive&cobra.ShellCompDirectiveNoFileComp == 0 {
+		a = ActionFiles() // default shell completion
+	}
+	if directive&cobra.ShellCompDirectiveNoSpace != 0 {
+		a = a.NoSpace()
+	}
+	return a
+}
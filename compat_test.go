@@ -61,3 +61,197 @@ func TestRegisterFlagCompletion(t *testing.T) {
 		t.Error("flag wrong")
 	}
 }
+
+func cobraFallbackCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "fallback",
+		ValidArgs: []string{"first\tfirst argument", "other"},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return []string{"func" + strings.Join(args, "")}, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(*cobra.Command, []string) {},
+	}
+	cmd.Flags().String("ext", "", "")
+	cmd.Flags().String("dirs", "", "")
+	cmd.Flags().String("values", "", "")
+	cmd.Flags().String("carapace", "", "")
+	_ = cmd.RegisterFlagCompletionFunc("ext", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"md"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	_ = cmd.RegisterFlagCompletionFunc("dirs", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	})
+	_ = cmd.RegisterFlagCompletionFunc("values", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"a\tdescription of a", "b"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("carapace", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"cobra"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	Gen(cmd).FlagCompletion(ActionMap{
+		"carapace": ActionValues("carapace"),
+	})
+	return cmd
+}
+
+func TestCobraFallback(t *testing.T) {
+	tests := map[string][]string{
+		"":                {`"first"`, `"first argument"`, `"other"`, `"func"`, `"nospace":"*"`},
+		"pos ":            {`"funcpos"`},
+		"--values ":       {`"a"`, `"description of a"`, `"b"`},
+		"--ext ":          {`"README.md"`},
+		"--dirs ":         {`"example/"`},
+		"--carapace ":     {`"carapace"`},
+		"pos --values=b ": {`"funcpos"`},
+	}
+	for args, expected := range tests {
+		a, err := complete(cobraFallbackCmd(), append([]string{"export", "fallback"}, strings.Split(args, " ")...))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, e := range expected {
+			if !strings.Contains(a, e) {
+				t.Errorf("expected %v for %#v: %v", e, args, a)
+			}
+		}
+	}
+
+	if a, _ := complete(cobraFallbackCmd(), []string{"export", "fallback", "pos", ""}); strings.Contains(a, `"first"`) {
+		t.Errorf("expected ValidArgs only for first argument: %v", a)
+	}
+	if a, _ := complete(cobraFallbackCmd(), []string{"export", "fallback", "--dirs", ""}); strings.Contains(a, `"README.md"`) {
+		t.Errorf("expected directories only: %v", a)
+	}
+	if a, _ := complete(cobraFallbackCmd(), []string{"export", "fallback", "--carapace", ""}); strings.Contains(a, `"cobra"`) {
+		t.Errorf("expected carapace action to take precedence: %v", a)
+	}
+}
+
+func TestCobraFallbackBridged(t *testing.T) {
+	cmd := &cobra.Command{Use: "bridged", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().String("flag", "", "")
+	Gen(cmd)
+	registerValidArgsFunction(cmd)
+	registerFlagCompletion(cmd)
+
+	if a, _ := complete(cmd, []string{"export", "bridged", "--flag", ""}); !strings.Contains(a, `"values":[]`) {
+		t.Errorf("expected no values: %v", a)
+	}
+	if a, _ := complete(cmd, []string{"export", "bridged", ""}); !strings.Contains(a, `"values":[]`) {
+		t.Errorf("expected no values: %v", a)
+	}
+
+	// a cobra fallback must not suppress bridged functions of other commands
+	delegateCmd := &cobra.Command{Use: "delegate", Run: func(*cobra.Command, []string) {}}
+	subCmd := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	delegateCmd.AddCommand(subCmd)
+	Gen(subCmd).PositionalCompletion(ActionValues("bridged"))
+	registerValidArgsFunction(subCmd)
+	delegateCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return subCmd.ValidArgsFunction(subCmd, args, toComplete)
+	}
+	if a, _ := complete(delegateCmd, []string{"export", "delegate", ""}); !strings.Contains(a, `"bridged"`) {
+		t.Errorf("expected values of bridged function: %v", a)
+	}
+}
+
+func TestActiveHelp(t *testing.T) {
+	cmd := &cobra.Command{Use: "activehelp [pos]..."}
+	cmd.Flags().String("flag", "", "flag usage")
+	cmd.Flags().String("explicit", "", "flag usage")
+	Gen(cmd).PositionalCompletion(
+		ActionMessage("some error").Usage("positional usage"),
+		ActionValues("b"),
+	)
+	Gen(cmd).FlagCompletion(ActionMap{
+		"flag":     ActionValues("a"),
+		"explicit": ActionValues("a").Usage("explicit usage"),
+	})
+	registerValidArgsFunction(cmd)
+	registerFlagCompletion(cmd)
+
+	vals, _ := cmd.ValidArgsFunction(cmd, []string{}, "")
+	if expected := cobra.AppendActiveHelp(cobra.AppendActiveHelp(nil, "positional usage"), "some error"); strings.Join(vals, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %#v: %#v", expected, vals)
+	}
+
+	if vals, _ = cmd.ValidArgsFunction(cmd, []string{"b"}, ""); strings.Join(vals, "\n") != "b" {
+		t.Errorf("expected default usage not to be passed: %#v", vals)
+	}
+
+	f, _ := cmd.GetFlagCompletionFunc("flag")
+	if vals, _ = f(cmd, []string{}, ""); strings.Join(vals, "\n") != "a" {
+		t.Errorf("expected default usage not to be passed: %#v", vals)
+	}
+
+	f, _ = cmd.GetFlagCompletionFunc("explicit")
+	vals, _ = f(cmd, []string{}, "")
+	if expected := cobra.AppendActiveHelp([]string{"a"}, "explicit usage"); strings.Join(vals, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %#v: %#v", expected, vals)
+	}
+
+	os.Setenv("COBRA_ACTIVE_HELP", "0")
+	defer os.Unsetenv("COBRA_ACTIVE_HELP")
+	f, _ = cmd.GetFlagCompletionFunc("explicit")
+	if vals, _ = f(cmd, []string{}, ""); strings.Join(vals, "\n") != "a" {
+		t.Errorf("expected ActiveHelp to be disabled: %#v", vals)
+	}
+}
+
+func TestActiveHelpFallback(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "activehelp",
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return cobra.AppendActiveHelp(nil, "too many arguments"), cobra.ShellCompDirectiveError
+			}
+			return cobra.AppendActiveHelp([]string{"a", "b"}, "first argument"), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(*cobra.Command, []string) {},
+	}
+
+	if a, _ := complete(cmd, []string{"export", "activehelp", ""}); !strings.Contains(a, `"usage":"first argument"`) || !strings.Contains(a, `"a"`) || strings.Contains(a, "_activeHelp_") {
+		t.Errorf("expected ActiveHelp as usage: %v", a)
+	}
+	if a, _ := complete(cmd, []string{"export", "activehelp", "a", ""}); !strings.Contains(a, `"messages":["too many arguments"]`) {
+		t.Errorf("expected ActiveHelp as message: %v", a)
+	}
+}
+
+func cobraFormatCmd() *cobra.Command {
+	cmd := &cobra.Command{Use: "format", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().String("flag", "", "flag usage")
+	Gen(cmd).PositionalCompletion(
+		ActionValuesDescribed("a", "first\tvalue", "b", "").Usage("positional usage"),
+		ActionMultiParts("/", func(c Context) Action {
+			return ActionValues("dir/", "file")
+		}),
+		ActionMessage("some error"),
+	)
+	return cmd
+}
+
+func TestCobraFormat(t *testing.T) {
+	tests := map[string]string{
+		"":        "a\tfirstvalue\nb\n_activeHelp_ positional usage\n:4",
+		"a ":      "dir/\nfile\n:6",
+		"a b ":    "_activeHelp_ some error\n:4",
+		"--flag ": "_activeHelp_ flag usage\n:4",
+	}
+	for args, expected := range tests {
+		a, err := complete(cobraFormatCmd(), append([]string{"cobra", "format"}, strings.Split(args, " ")...))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if a != expected {
+			t.Errorf("expected %#v for %#v: %#v", expected, args, a)
+		}
+	}
+
+	for _, env := range []string{"COBRA_ACTIVE_HELP", "FORMAT_ACTIVE_HELP"} {
+		os.Setenv(env, "0")
+		if a, _ := complete(cobraFormatCmd(), []string{"cobra", "format", ""}); a != "a\tfirstvalue\nb\n:4" {
+			t.Errorf("expected ActiveHelp to be disabled by %v: %#v", env, a)
+		}
+		os.Unsetenv(env)
+	}
+}
@@ -2,6 +2,7 @@ package carapace
 
 import (
 	"github.com/rsteube/carapace/internal/config"
+	"github.com/rsteube/carapace/internal/env"
 	"github.com/rsteube/carapace/pkg/ps"
 	"github.com/spf13/cobra"
 )
@@ -17,6 +18,9 @@ func complete(cmd *cobra.Command, args []string) (string, error) {
 		if err := config.Load(); err != nil {
 			action = ActionMessage("failed to load config: " + err.Error())
 		}
-		return action.Invoke(context).value(args[0], args[len(args)-1]), nil
+		if timeout := env.Timeout(); timeout > 0 {
+			action = action.Timeout(timeout, ActionMessage("completion timeout exceeded [%v]", timeout))
+		}
+		return action.Invoke(context).value(args[0], args[len(args)-1], cobra.GetActiveHelpConfig(cmd)), nil
 	}
 }
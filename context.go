@@ -1,17 +1,20 @@
 package carapace
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/rsteube/carapace/internal/common"
 	"github.com/rsteube/carapace/internal/env"
 	"github.com/rsteube/carapace/internal/shell/zsh"
 	"github.com/rsteube/carapace/third_party/github.com/drone/envsubst"
 	"github.com/rsteube/carapace/third_party/golang.org/x/sys/execabs"
+	"github.com/spf13/pflag"
 )
 
 // Context provides information during completion.
@@ -28,6 +31,8 @@ type Context struct {
 	Dir string
 
 	mockedReplies map[string]string
+	flagSet       *pflag.FlagSet
+	ctx           context.Context
 }
 
 // NewContext creates a new context for given arguments.
@@ -81,14 +86,98 @@ func (c *Context) Setenv(key, value string) {
 	c.Env = append(c.Env, fmt.Sprintf("%v=%v", key, value))
 }
 
+func (c Context) flags() *pflag.FlagSet {
+	if c.flagSet == nil {
+		return pflag.NewFlagSet("", pflag.ContinueOnError)
+	}
+	return c.flagSet
+}
+
+// Flag returns the flag with given name of the current (sub)command (nil if it does not exist).
+//
+//	if flag := c.Flag("output"); flag != nil && flag.Changed {
+//		return carapace.ActionMessage("output is set to: %v", flag.Value.String())
+//	}
+func (c Context) Flag(name string) *pflag.Flag {
+	return c.flags().Lookup(name)
+}
+
+// FlagChanged returns true if the flag with given name was set.
+func (c Context) FlagChanged(name string) bool {
+	return c.flags().Changed(name)
+}
+
+// GetBool returns the value of a bool flag (false if it does not exist).
+func (c Context) GetBool(name string) bool {
+	v, _ := c.flags().GetBool(name)
+	return v
+}
+
+// GetCount returns the value of a count flag (0 if it does not exist).
+func (c Context) GetCount(name string) int {
+	v, _ := c.flags().GetCount(name)
+	return v
+}
+
+// GetInt returns the value of an int flag (0 if it does not exist).
+func (c Context) GetInt(name string) int {
+	v, _ := c.flags().GetInt(name)
+	return v
+}
+
+// GetString returns the value of a string flag ("" if it does not exist).
+func (c Context) GetString(name string) string {
+	v, _ := c.flags().GetString(name)
+	return v
+}
+
+// GetStringArray returns the value of a string array flag (empty if it does not exist).
+func (c Context) GetStringArray(name string) []string {
+	v, _ := c.flags().GetStringArray(name)
+	return v
+}
+
+// GetStringSlice returns the value of a string slice flag (empty if it does not exist).
+func (c Context) GetStringSlice(name string) []string {
+	v, _ := c.flags().GetStringSlice(name)
+	return v
+}
+
+// Done returns a channel that is closed when the invocation is cancelled (e.g. Timeout exceeded).
+// It is nil if the Context can't be cancelled.
+func (c Context) Done() <-chan struct{} {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Done()
+}
+
+// Err returns a non-nil error if the invocation was cancelled.
+func (c Context) Err() error {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Err()
+}
+
+func (c Context) withTimeout(d time.Duration) (Context, context.CancelFunc) {
+	parent := c.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(parent, d)
+	c.ctx = ctx
+	return c, cancel
+}
+
 // Envsubst replaces ${var} in the string based on environment variables in current context.
 func (c Context) Envsubst(s string) (string, error) {
 	return envsubst.Eval(s, c.Getenv)
 }
 
 // Command returns the Cmd struct to execute the named program with the given arguments.
-// Env and Dir are set using the Context.
-// See exec.Command for most details.
+// Env and Dir are set using the Context and the process is killed if the invocation is cancelled.
+// See exec.CommandContext for most details.
 func (c Context) Command(name string, arg ...string) *execabs.Cmd {
 	if c.mockedReplies != nil {
 		if m, err := json.Marshal(append([]string{name}, arg...)); err == nil {
@@ -98,7 +187,12 @@ func (c Context) Command(name string, arg ...string) *execabs.Cmd {
 		}
 	}
 
-	cmd := execabs.Command(name, arg...)
+	var cmd *execabs.Cmd
+	if c.ctx != nil {
+		cmd = execabs.CommandContext(c.ctx, name, arg...)
+	} else {
+		cmd = execabs.Command(name, arg...)
+	}
 	cmd.Env = c.Env
 	cmd.Dir = c.Dir
 	return cmd
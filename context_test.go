@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func wd(s string) string {
@@ -108,3 +110,52 @@ func TestEnvsubst(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestContextFlags(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "root",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.PersistentFlags().Bool("persistent", false, "")
+	cmd.Flags().String("string", "", "")
+	cmd.Flags().CountP("count", "c", "")
+	cmd.Flags().StringSlice("slice", []string{}, "")
+
+	subcmd := &cobra.Command{
+		Use: "sub",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	subcmd.Flags().Int("int", 0, "")
+	cmd.AddCommand(subcmd)
+
+	var context Context
+	capture := ActionCallback(func(c Context) Action {
+		context = c
+		return ActionValues()
+	})
+	Gen(cmd).PositionalAnyCompletion(capture)
+	Gen(subcmd).PositionalAnyCompletion(capture)
+
+	_, _ = complete(cmd, []string{"export", "_", "--string", "value", "-cc", "--slice", "a,b", ""})
+	if !context.FlagChanged("string") || context.GetString("string") != "value" {
+		t.Error("string flag")
+	}
+	if context.GetCount("count") != 2 {
+		t.Error("count flag")
+	}
+	if s := context.GetStringSlice("slice"); len(s) != 2 || s[1] != "b" {
+		t.Error("slice flag")
+	}
+	if context.FlagChanged("persistent") || context.Flag("unknown") != nil {
+		t.Error("unchanged or unknown flag")
+	}
+
+	_, _ = complete(cmd, []string{"export", "_", "--persistent", "sub", "--int", "3", ""})
+	if !context.GetBool("persistent") || context.GetInt("int") != 3 {
+		t.Error("subcommand flags")
+	}
+
+	if (Context{}).FlagChanged("string") || (Context{}).GetString("string") != "" {
+		t.Error("context without flags")
+	}
+}
@@ -132,14 +132,14 @@ func ActionExecute(cmd *cobra.Command) Action {
 func ActionDirectories() Action {
 	return ActionCallback(func(c Context) Action {
 		return actionPath([]string{""}, true).Invoke(c).ToMultiPartsA("/").StyleF(style.ForPath)
-	}).Tag("directories")
+	}).Tag("directories").withSpec("$directories")
 }
 
 // ActionFiles completes files with optional suffix filtering.
 func ActionFiles(suffix ...string) Action {
 	return ActionCallback(func(c Context) Action {
 		return actionPath(suffix, false).Invoke(c).ToMultiPartsA("/").StyleF(style.ForPath)
-	}).Tag("files")
+	}).Tag("files").withSpec(macro("files", suffix...))
 }
 
 // ActionValues completes arbitrary keywords (values).
@@ -150,7 +150,7 @@ func ActionValues(values ...string) Action {
 			vals = append(vals, common.RawValue{Value: val, Display: val})
 		}
 		return Action{rawValues: vals}
-	})
+	}).withSpec(values...).asStatic()
 }
 
 // ActionStyledValues is like ActionValues but also accepts a style.
@@ -165,7 +165,7 @@ func ActionStyledValues(values ...string) Action {
 			vals = append(vals, common.RawValue{Value: values[i], Display: values[i], Style: values[i+1]})
 		}
 		return Action{rawValues: vals}
-	})
+	}).withSpec(specValues(values, false, true)...).asStatic()
 }
 
 // ActionValuesDescribed completes arbitrary key (values) with an additional description (value, description pairs).
@@ -180,7 +180,7 @@ func ActionValuesDescribed(values ...string) Action {
 			vals = append(vals, common.RawValue{Value: values[i], Display: values[i], Description: values[i+1]})
 		}
 		return Action{rawValues: vals}
-	})
+	}).withSpec(specValues(values, true, false)...).asStatic()
 }
 
 // ActionStyledValuesDescribed is like ActionValues but also accepts a style.
@@ -195,7 +195,7 @@ func ActionStyledValuesDescribed(values ...string) Action {
 			vals = append(vals, common.RawValue{Value: values[i], Display: values[i], Description: values[i+1], Style: values[i+2]})
 		}
 		return Action{rawValues: vals}
-	})
+	}).withSpec(specValues(values, true, true)...).asStatic()
 }
 
 // ActionMessage displays a help messages in places where no completions can be generated.
@@ -408,7 +408,7 @@ func ActionExecutables() Action {
 			batch = append(batch, actionDirectoryExecutables(dirs[i], c.Value, manDescriptions))
 		}
 		return batch.ToA()
-	}).Tag("executables")
+	}).Tag("executables").withSpec("$executables")
 }
 
 func actionDirectoryExecutables(dir string, prefix string, manDescriptions map[string]string) Action {
@@ -447,7 +447,7 @@ func ActionPositional(cmd *cobra.Command) Action {
 		c.Args = cmd.Flags().Args()
 		entry := storage.get(cmd)
 
-		a := entry.positionalAny
+		a := orEmpty(entry.positionalAny)
 		if index := len(c.Args); index < len(entry.positional) {
 			a = entry.positional[len(c.Args)]
 		}
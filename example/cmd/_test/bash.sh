@@ -4,7 +4,7 @@ _example_completion() {
 
   local compline="${COMP_LINE:0:${COMP_POINT}}"
   local IFS=$'\n'
-  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs example _carapace bash)
+  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | CARAPACE_QUOTE="${COMP_WORDS[COMP_CWORD]:0:1}" xargs example _carapace bash)
   [[ "${COMPREPLY[*]}" == "" ]] && COMPREPLY=() # fix for mapfile creating a non-empty array from empty command output
 
   compopt -o nospace
@@ -0,0 +1,26 @@
+local _example_generator = clink.generator(1)
+
+function _example_generator:generate(line_state, match_builder)
+  if path.getbasename(line_state:getword(1)) ~= 'example' then
+    return false
+  end
+
+  -- words are passed by environment as io.popen runs the command with cmd.exe (metacharacters like '&' would be interpreted)
+  local words = {}
+  for i = 1, line_state:getwordcount() do
+    table.insert(words, line_state:getword(i))
+  end
+
+  os.setenv('CARAPACE_CLINK_WORDS', table.concat(words, '\n'))
+  local output = io.popen('example _carapace clink')
+  for line in output:lines() do
+    local value, display, description, nospace = line:match('^(.-)\t(.-)\t(.-)\t(.-)$')
+    if value then
+      match_builder:addmatch({match = value, display = display, description = description, type = 'word', suppressappend = nospace == 'true'})
+    end
+  end
+  output:close()
+  os.setenv('CARAPACE_CLINK_WORDS', nil)
+  return true -- prevent default file completion
+end
+
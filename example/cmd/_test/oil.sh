@@ -2,7 +2,7 @@
 _example_completion() {
   local compline="${COMP_LINE:0:${COMP_POINT}}"
   local IFS=$'\n'
-  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs example _carapace oil)
+  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | CARAPACE_QUOTE="${COMP_WORDS[COMP_CWORD]:0:1}" xargs example _carapace oil)
   [[ "${COMPREPLY[@]}" == "" ]] && COMPREPLY=() # fix for mapfile creating a non-empty array from empty command output
   [[ ${COMPREPLY[0]} == *[/=@:.,$'\001'] ]] && compopt -o nospace
   # TODO use mapfile
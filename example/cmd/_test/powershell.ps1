@@ -28,6 +28,11 @@ Function _example_completer {
       $elems += $t.replace('`,', ',') # quick fix
     }
 
+    $env:CARAPACE_QUOTE = ''
+    if ($wordToComplete -match '^[''"]') {
+      $env:CARAPACE_QUOTE = $wordToComplete.Substring(0,1)
+    }
+
     $completions = @(
       if (!$wordToComplete) {
         example _carapace powershell $($elems| ForEach-Object {$_}) '' | ConvertFrom-Json | ForEach-Object { [CompletionResult]::new($_.CompletionText, $_.ListItemText.replace('`e[', "`e["), [CompletionResultType]::ParameterValue, $_.ToolTip) }
@@ -35,6 +40,7 @@ Function _example_completer {
         example _carapace powershell $($elems| ForEach-Object {$_}) | ConvertFrom-Json | ForEach-Object { [CompletionResult]::new($_.CompletionText, $_.ListItemText.replace('`e[', "`e["), [CompletionResultType]::ParameterValue, $_.ToolTip) }
       }
     )
+    $env:CARAPACE_QUOTE = $null
 
     if ($completions.count -eq 0) {
       return "" # prevent default file completion
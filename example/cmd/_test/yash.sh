@@ -0,0 +1,4 @@
+function completion/example {
+  eval "$(example _carapace yash "${WORDS}" "${TARGETWORD}")"
+}
+
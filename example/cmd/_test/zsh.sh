@@ -4,11 +4,11 @@ function _example_completion {
   
   # shellcheck disable=SC2086,SC2154,SC2155
   if echo ${words}"''" | xargs echo 2>/dev/null > /dev/null; then
-    local lines="$(echo ${words}"''" | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh )"
+    local lines="$(echo ${words}"''" | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh )"
   elif echo ${words} | sed "s/\$/'/" | xargs echo 2>/dev/null > /dev/null; then
-    local lines="$(echo ${words} | sed "s/\$/'/" | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh)"
+    local lines="$(echo ${words} | sed "s/\$/'/" | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh)"
   else
-    local lines="$(echo ${words} | sed 's/$/"/' | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh)"
+    local lines="$(echo ${words} | sed 's/$/"/' | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs example _carapace zsh)"
   fi
 
   local zstyle message data
@@ -16,6 +16,7 @@ function _example_completion {
   # shellcheck disable=SC2154
   zstyle ":completion:${curcontext}:*" list-colors "${zstyle}"
   zstyle ":completion:${curcontext}:*" group-name ''
+  zstyle -T ":completion:${curcontext}:descriptions" format && zstyle ":completion:${curcontext}:descriptions" format $'%B%d%b'
   [ -z "$message" ] || _message -r "${message}"
   
   local block tag displays values displaysArr valuesArr
@@ -37,8 +37,8 @@ func init() {
 
 	carapace.Gen(actionCmd).FlagCompletion(carapace.ActionMap{
 		"callback": carapace.ActionCallback(func(c carapace.Context) carapace.Action {
-			if flag := actionCmd.Flag("values"); flag.Changed {
-				return carapace.ActionMessage("values flag is set to: '%v'", flag.Value.String())
+			if c.FlagChanged("values") {
+				return carapace.ActionMessage("values flag is set to: '%v'", c.GetString("values"))
 			}
 			return carapace.ActionMessage("values flag is not set")
 		}),
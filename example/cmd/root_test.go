@@ -28,6 +28,10 @@ func TestBashBle(t *testing.T) {
 	testScript(t, "bash-ble", "./_test/bash-ble.sh")
 }
 
+func TestClink(t *testing.T) {
+	testScript(t, "clink", "./_test/clink.lua")
+}
+
 func TestElvish(t *testing.T) {
 	testScript(t, "elvish", "./_test/elvish.elv")
 }
@@ -36,6 +40,10 @@ func TestFish(t *testing.T) {
 	testScript(t, "fish", "./_test/fish.fish")
 }
 
+func TestMurex(t *testing.T) {
+	testScript(t, "murex", "./_test/murex.mx")
+}
+
 func TestNushell(t *testing.T) {
 	testScript(t, "nushell", "./_test/nushell.nu")
 }
@@ -52,6 +60,10 @@ func TestXonsh(t *testing.T) {
 	testScript(t, "xonsh", "./_test/xonsh.py")
 }
 
+func TestYash(t *testing.T) {
+	testScript(t, "yash", "./_test/yash.sh")
+}
+
 func TestZsh(t *testing.T) {
 	testScript(t, "zsh", "./_test/zsh.sh")
 }
@@ -1,4 +1,4 @@
-// Package cache provides disk cache for Actions
+// Package cache provides cache for Actions
 package cache
 
 import (
@@ -7,8 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rsteube/carapace/internal/export"
@@ -17,43 +20,211 @@ import (
 	"github.com/rsteube/carapace/pkg/xdg"
 )
 
-// Write persistests given values to file as json.
-func Write(file string, e export.Export) (err error) {
-	var m []byte
-	if m, err = json.Marshal(e); err == nil {
-		err = os.WriteFile(file, m, 0600)
+var config struct {
+	sync.RWMutex
+	backend cache.Cache
+	size    int64
+	age     time.Duration
+	evicted time.Time // last eviction of a custom backend
+}
+
+// Set sets the cache backend (nil restores the default).
+func Set(c cache.Cache) {
+	config.Lock()
+	defer config.Unlock()
+	config.backend = c
+	config.evicted = time.Time{}
+}
+
+// SetLimits sets the maximum total size and age of the cache (0 disables the limit).
+func SetLimits(size int64, age time.Duration) {
+	config.Lock()
+	defer config.Unlock()
+	config.size = size
+	config.age = age
+}
+
+// Get returns the current cache backend which defaults to a directory in the user cache dir.
+func Get() (cache.Cache, error) {
+	config.RLock()
+	defer config.RUnlock()
+
+	if config.backend != nil {
+		return config.backend, nil
+	}
+
+	userCacheDir, err := xdg.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Directory(fmt.Sprintf("%v/carapace/%v", userCacheDir, uid.Executable())), nil
+}
+
+// entry is the persisted format of a cached Action.
+type entry struct {
+	Caller string        `json:"caller"`
+	Export export.Export `json:"export"`
+}
+
+// Write persistests given values as json.
+func Write(key string, caller string, e export.Export) (err error) {
+	var c cache.Cache
+	if c, err = Get(); err == nil {
+		var m []byte
+		if m, err = json.Marshal(entry{Caller: caller, Export: e}); err == nil {
+			if err = c.Write(key, m); err == nil {
+				err = evict(c)
+			}
+		}
 	}
 	return
 }
 
-// Load loads values from file unless modification date exceeds timeout.
-func Load(file string, timeout time.Duration) (e export.Export, err error) {
-	var stat os.FileInfo
-	if stat, err = os.Stat(file); os.IsNotExist(err) || (timeout > 0 && stat.ModTime().Add(timeout).Before(time.Now())) {
-		err = errors.New("not exists or timeout exceeded")
-	} else {
-		var content []byte
-		if content, err = os.ReadFile(file); err == nil {
-			err = json.Unmarshal(content, &e)
+// EvictInterval limits how often eviction lists the whole cache (0 evicts on every write).
+var EvictInterval = time.Hour
+
+// evictDue returns true if the last eviction exceeds EvictInterval.
+// It is tracked with a marker file shared between processes for the default directory and in-process for custom backends.
+func evictDue() bool {
+	if EvictInterval <= 0 {
+		return true
+	}
+
+	config.Lock()
+	defer config.Unlock()
+
+	if config.backend != nil {
+		if time.Since(config.evicted) < EvictInterval {
+			return false
+		}
+		config.evicted = time.Now()
+		return true
+	}
+
+	userCacheDir, err := xdg.UserCacheDir()
+	if err != nil {
+		return true
+	}
+	marker := fmt.Sprintf("%v/carapace/%v.evicted", userCacheDir, uid.Executable())
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < EvictInterval {
+		return false
+	}
+
+	if err := os.MkdirAll(filepath.Dir(marker), 0700); err == nil {
+		if f, err := os.Create(marker); err == nil { // also updates the modification time
+			f.Close()
 		}
 	}
+	return true
+}
+
+// evict removes entries exceeding the maximum age as well as least recently used ones exceeding the maximum size.
+func evict(c cache.Cache) error {
+	config.RLock()
+	size, age := config.size, config.age
+	config.RUnlock()
+
+	if (size <= 0 && age <= 0) || !evictDue() {
+		return nil
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].AccessTime.Before(entries[j].AccessTime) })
+
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	for _, e := range entries {
+		expired := age > 0 && time.Since(e.ModTime) > age
+		exceeded := size > 0 && total > size
+		if !expired && !exceeded {
+			continue
+		}
+		if err := c.Delete(e.Key); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= e.Size
+	}
+	return nil
+}
+
+// Load loads values unless modification date exceeds timeout.
+func Load(key string, timeout time.Duration) (e export.Export, err error) {
+	e, _, err = LoadStale(key, timeout, 0)
 	return
 }
 
-// CacheDir creates a cache folder for current user and returns the path.
-func CacheDir(name string) (dir string, err error) {
-	var userCacheDir string
-	if userCacheDir, err = xdg.UserCacheDir(); err == nil {
-		dir = fmt.Sprintf("%v/carapace/%v/%v", userCacheDir, uid.Executable(), name)
-		err = os.MkdirAll(dir, 0700)
+// LoadStale loads values unless modification date exceeds timeout and maxStale.
+// Values older than timeout are marked as stale.
+func LoadStale(key string, timeout, maxStale time.Duration) (e export.Export, stale bool, err error) {
+	var c cache.Cache
+	if c, err = Get(); err == nil {
+		var content []byte
+		var modTime time.Time
+		if content, modTime, err = c.Load(key); err != nil || (timeout > 0 && modTime.Add(timeout+maxStale).Before(time.Now())) {
+			err = errors.New("not exists or timeout exceeded")
+		} else {
+			var cached entry
+			if err = json.Unmarshal(content, &cached); err == nil {
+				if cached.Export.Version == "" {
+					return e, false, errors.New("invalid cache entry") // older format
+				}
+				e = cached.Export
+				stale = timeout > 0 && modTime.Add(timeout).Before(time.Now())
+			}
+		}
 	}
 	return
 }
 
-// File returns the cache filename for given values
-// TODO cleanup
-func File(callerFile string, callerLine int, keys ...cache.Key) (file string, err error) {
-	uid := uidKeys(callerFile, strconv.Itoa(callerLine))
+// Info describes a cache entry.
+type Info struct {
+	cache.Entry
+	Caller string // file:line where the Action was cached
+}
+
+// List returns all cache entries.
+func List() ([]Info, error) {
+	c, err := Get()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, 0, len(entries))
+	for _, e := range entries {
+		info := Info{Entry: e}
+		if content, err := c.Peek(e.Key); err == nil { // Load would alter the access time used for eviction
+			var cached entry
+			if err := json.Unmarshal(content, &cached); err == nil {
+				info.Caller = cached.Caller
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// Delete removes the cache entry for given key.
+func Delete(key string) error {
+	c, err := Get()
+	if err != nil {
+		return err
+	}
+	return c.Delete(key)
+}
+
+// Key returns the cache key for given values.
+func Key(callerFile string, callerLine int, keys ...cache.Key) (string, error) {
 	ids := make([]string, 0)
 	for _, key := range keys {
 		id, err := key()
@@ -62,10 +233,7 @@ func File(callerFile string, callerLine int, keys ...cache.Key) (file string, er
 		}
 		ids = append(ids, id)
 	}
-	if dir, err := CacheDir(uid); err == nil {
-		file = dir + "/" + uidKeys(ids...)
-	}
-	return
+	return uidKeys(callerFile, strconv.Itoa(callerLine)) + "/" + uidKeys(ids...), nil
 }
 
 func uidKeys(keys ...string) string {
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rsteube/carapace/internal/export"
+	"github.com/rsteube/carapace/pkg/cache"
+)
+
+func TestEvictCustomBackend(t *testing.T) {
+	cacheHome := t.TempDir()
+	xdgCacheHome, ok := os.LookupEnv("XDG_CACHE_HOME")
+	os.Setenv("XDG_CACHE_HOME", cacheHome)
+	defer func() {
+		if ok {
+			os.Setenv("XDG_CACHE_HOME", xdgCacheHome)
+		} else {
+			os.Unsetenv("XDG_CACHE_HOME")
+		}
+	}()
+
+	Set(cache.Memory())
+	SetLimits(1, 0)
+	defer Set(nil)
+	defer SetLimits(0, 0)
+
+	if err := Write("first", "", export.Export{Version: "test"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if entries, _ := List(); len(entries) != 0 {
+		t.Errorf("expected entry exceeding the size to be evicted: %v", entries)
+	}
+
+	if err := Write("second", "", export.Export{Version: "test"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if entries, _ := List(); len(entries) != 1 {
+		t.Errorf("expected eviction to be throttled: %v", entries)
+	}
+
+	if entries, _ := os.ReadDir(cacheHome); len(entries) != 0 {
+		t.Errorf("expected no files for memory backend: %v", entries)
+	}
+
+	Set(cache.Memory())
+	if !evictDue() {
+		t.Error("expected eviction to be due for a new backend")
+	}
+	if evictDue() {
+		t.Errorf("expected eviction to be throttled [%v]", EvictInterval)
+	}
+}
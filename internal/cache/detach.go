@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package cache
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the process in a new session so it survives the shell cancelling the completion.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
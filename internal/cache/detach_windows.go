@@ -0,0 +1,11 @@
+package cache
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the process in a new process group so it survives the shell cancelling the completion.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
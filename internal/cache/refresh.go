@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/pkg/xdg"
+	"github.com/rsteube/carapace/third_party/golang.org/x/sys/execabs"
+)
+
+// refreshLockTimeout is the age after which a refresh lock is considered stale (e.g. the refreshing process got killed).
+const refreshLockTimeout = time.Minute
+
+func refreshLock(key string) (string, error) {
+	dir := fmt.Sprintf("%v/carapace/refresh/%v", xdg.UserRuntimeDir(), uid.Executable())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v/%v", dir, uidKeys(key)), nil
+}
+
+// acquireRefreshLock returns false if a refresh for key is already in progress.
+func acquireRefreshLock(lock string) (bool, error) {
+	f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(lock); statErr != nil || time.Since(info.ModTime()) < refreshLockTimeout {
+			return false, nil
+		}
+		if err := os.Remove(lock); err != nil && !os.IsNotExist(err) {
+			return false, err
+		}
+		f, err = os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if os.IsExist(err) { // another process took over the stale lock
+			return false, nil
+		}
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// Refresh starts a detached process of the current executable with given args
+// which refreshes the cache entry for key.
+// It is skipped if a refresh for key is already in progress.
+func Refresh(key string, args ...string) error {
+	lock, err := refreshLock(key)
+	if err != nil {
+		return err
+	}
+	if acquired, err := acquireRefreshLock(lock); err != nil || !acquired {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		os.Remove(lock)
+		return err
+	}
+
+	cmd := execabs.Command(executable, args...)
+	cmd.Env = append(os.Environ(), "CARAPACE_CACHE_REFRESH="+key)
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		os.Remove(lock)
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// Refreshed releases the refresh lock for key (called by the refreshing process).
+func Refreshed(key string) {
+	if lock, err := refreshLock(key); err == nil {
+		os.Remove(lock)
+	}
+}
@@ -0,0 +1,27 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefreshLock(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "lock")
+
+	if acquired, err := acquireRefreshLock(lock); err != nil || !acquired {
+		t.Fatalf("expected lock to be acquired: %v", err)
+	}
+	if acquired, err := acquireRefreshLock(lock); err != nil || acquired {
+		t.Errorf("expected lock to be held: %v", err)
+	}
+
+	stale := time.Now().Add(-2 * refreshLockTimeout)
+	if err := os.Chtimes(lock, stale, stale); err != nil {
+		t.Fatal(err)
+	}
+	if acquired, err := acquireRefreshLock(lock); err != nil || !acquired {
+		t.Errorf("expected stale lock to be taken over: %v", err)
+	}
+}
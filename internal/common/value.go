@@ -2,6 +2,7 @@
 package common
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 
@@ -92,6 +93,28 @@ func (r RawValues) Decolor() RawValues {
 	return rawValues
 }
 
+// TaggedDescription returns the trimmed description prefixed with the tag.
+func (r RawValue) TaggedDescription() string {
+	switch description := r.TrimmedDescription(); {
+	case r.Tag == "":
+		return description
+	case description == "":
+		return r.Tag
+	default:
+		return fmt.Sprintf("%v: %v", r.Tag, description)
+	}
+}
+
+// Tagged checks if values are spread over more than one tag.
+func (r RawValues) Tagged() bool {
+	for _, val := range r {
+		if val.Tag != r[0].Tag {
+			return true
+		}
+	}
+	return false
+}
+
 // FilterPrefix filters values with given prefix.
 func (r RawValues) FilterPrefix(prefix string) RawValues {
 	filtered := make(RawValues, 0)
@@ -103,6 +126,7 @@ func (r RawValues) FilterPrefix(prefix string) RawValues {
 	return filtered
 }
 
+// EachTag calls f for each tag in alphabetical order with the values of that tag.
 func (r RawValues) EachTag(f func(tag string, values RawValues)) {
 	tagGroups := make(map[string]RawValues)
 	for _, val := range r {
@@ -14,6 +14,27 @@ func TestTrimmedDescription(t *testing.T) {
 	}
 }
 
+func TestTaggedDescription(t *testing.T) {
+	if s := (RawValue{Tag: "files", Description: "desc"}).TaggedDescription(); s != "files: desc" {
+		t.Errorf("unexpected: %v", s)
+	}
+	if s := (RawValue{Tag: "files"}).TaggedDescription(); s != "files" {
+		t.Errorf("unexpected: %v", s)
+	}
+	if s := (RawValue{Description: "desc"}).TaggedDescription(); s != "desc" {
+		t.Errorf("unexpected: %v", s)
+	}
+}
+
+func TestTagged(t *testing.T) {
+	if (RawValues{{Tag: "a"}, {Tag: "a"}}).Tagged() {
+		t.Error("single tag should not be tagged")
+	}
+	if !(RawValues{{Tag: "a"}, {Tag: "b"}}).Tagged() {
+		t.Error("multiple tags should be tagged")
+	}
+}
+
 func TestRawValuesFrom(t *testing.T) {
 	v := RawValuesFrom("first", "second")
 	if !equalRawValues(v[0], RawValue{
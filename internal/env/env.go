@@ -1,6 +1,10 @@
 package env
 
-import "os"
+import (
+	"os"
+	"strings"
+	"time"
+)
 
 func ColorDisabled() bool {
 	return os.Getenv("NO_COLOR") != "" || os.Getenv("CLICOLOR") == "0"
@@ -18,6 +22,40 @@ func Sandbox() string {
 	return os.Getenv("CARAPACE_SANDBOX")
 }
 
+// CacheDisabled returns true if cached Actions should always invoke their callback (`CARAPACE_CACHE_DISABLED` is set).
+func CacheDisabled() bool {
+	return os.Getenv("CARAPACE_CACHE_DISABLED") != ""
+}
+
 func Log() bool {
 	return os.Getenv("CARAPACE_LOG") != ""
 }
+
+// Timeout returns the maximum duration a completion may take (0 if not set or invalid).
+func Timeout() time.Duration {
+	d, _ := time.ParseDuration(os.Getenv("CARAPACE_TIMEOUT"))
+	return d
+}
+
+// CacheRefresh returns the cache key refreshed by a background process (empty if not set).
+func CacheRefresh() string {
+	return os.Getenv("CARAPACE_CACHE_REFRESH")
+}
+
+// Server returns true if snippets should forward completion requests to the completion server.
+func Server() bool {
+	return os.Getenv("CARAPACE_SERVER") != ""
+}
+
+// ClinkWords returns the words of the command line passed by the clink snippet (empty if not set).
+func ClinkWords() []string {
+	if words := os.Getenv("CARAPACE_CLINK_WORDS"); words != "" {
+		return strings.Split(words, "\n")
+	}
+	return nil
+}
+
+// Quote returns the opening quote of the word being completed (empty if not set).
+func Quote() string {
+	return os.Getenv("CARAPACE_QUOTE")
+}
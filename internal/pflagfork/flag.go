@@ -3,25 +3,52 @@ package pflagfork
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/rsteube/carapace/pkg/style"
 	"github.com/spf13/pflag"
 )
 
-// mode defines how flags are represented.
-type mode int
+// Mode defines how flags are represented.
+type Mode int
 
 const (
-	Default         mode = iota // default behaviour
+	Default         Mode = iota // default behaviour
 	ShorthandOnly               // only the shorthand should be used
 	NameAsShorthand             // non-posix mode where the name is also added as shorthand (single `-` prefix)
 )
 
+// AnnotationNargs defines the minimum and maximum amount of arguments consumed by a flag (maximum is `-1` if unlimited).
+const AnnotationNargs = "carapace_annotation_nargs"
+
+// AnnotationNegatable marks a bool flag as negatable (`--no-<name>`) with the annotated usage.
+const AnnotationNegatable = "carapace_annotation_negatable"
+
 type Flag struct {
 	*pflag.Flag
 }
 
+// Negation returns the usage of `--no-<name>` and whether the flag is negatable at all.
+func (f Flag) Negation() (usage string, ok bool) {
+	if f.Mode() != Default || f.Value.Type() != "bool" {
+		return "", false
+	}
+	if annotation, ok := f.Annotations[AnnotationNegatable]; ok {
+		if len(annotation) > 0 {
+			usage = annotation[0]
+		}
+		return usage, true
+	}
+	return "", false
+}
+
+// IsNegatable checks if the flag can be negated with `--no-<name>`.
+func (f Flag) IsNegatable() bool {
+	_, ok := f.Negation()
+	return ok
+}
+
 func (f Flag) Nargs() int {
 	if field := reflect.ValueOf(f.Flag).Elem().FieldByName("Nargs"); field.IsValid() && field.Kind() == reflect.Int {
 		return int(field.Int())
@@ -29,13 +56,57 @@ func (f Flag) Nargs() int {
 	return 0
 }
 
-func (f Flag) Mode() mode {
+// NargsRange returns the minimum and maximum amount of arguments consumed by the flag (maximum is `-1` if unlimited).
+func (f Flag) NargsRange() (min, max int) {
+	if annotation, ok := f.Annotations[AnnotationNargs]; ok && len(annotation) == 2 {
+		min, errMin := strconv.Atoi(annotation[0])
+		max, errMax := strconv.Atoi(annotation[1])
+		if errMin == nil && errMax == nil {
+			return min, max
+		}
+	}
+
+	switch nargs := f.Nargs(); {
+	case nargs > 1:
+		return nargs, nargs
+	case nargs < 0:
+		return 1, -1
+	default:
+		return 1, 1
+	}
+}
+
+// SetNargs sets the minimum and maximum amount of arguments consumed by the flag (maximum is `-1` if unlimited).
+func (f Flag) SetNargs(min, max int) error {
+	if min < 1 || (max >= 0 && max < min) {
+		return fmt.Errorf("invalid nargs range [%v]: %v..%v", f.Name, min, max)
+	}
+	if f.Annotations == nil {
+		f.Annotations = make(map[string][]string)
+	}
+	f.Annotations[AnnotationNargs] = []string{strconv.Itoa(min), strconv.Itoa(max)}
+	return nil
+}
+
+func (f Flag) Mode() Mode {
 	if field := reflect.ValueOf(f.Flag).Elem().FieldByName("Mode"); field.IsValid() && field.Kind() == reflect.Int {
-		return mode(field.Int())
+		return Mode(field.Int())
 	}
 	return Default
 }
 
+// SetMode sets the mode (only supported by carapace-pflag).
+func (f Flag) SetMode(m Mode) error {
+	if m == Default {
+		return nil
+	}
+	if field := reflect.ValueOf(f.Flag).Elem().FieldByName("Mode"); field.IsValid() && field.Kind() == reflect.Int && field.CanSet() {
+		field.SetInt(int64(m))
+		return nil
+	}
+	return fmt.Errorf("flag mode not supported [%v]: requires carapace-pflag", f.Name)
+}
+
 func (f Flag) OptargDelimiter() rune {
 	if field := reflect.ValueOf(f.Flag).Elem().FieldByName("OptargDelimiter"); field.IsValid() && field.Kind() == reflect.Int32 {
 		return (rune(field.Int()))
@@ -73,7 +144,7 @@ func (f Flag) Matches(arg string, posix bool) bool {
 		case ShorthandOnly, NameAsShorthand:
 			return false
 		default:
-			return name == f.Name
+			return name == f.Name || (f.IsNegatable() && name == "no-"+f.Name)
 		}
 
 	case !posix:
@@ -108,6 +179,12 @@ func (f Flag) TakesValue() bool {
 	}
 }
 
+// IsMultiArg checks if the flag consumes more than one argument.
+func (f Flag) IsMultiArg() bool {
+	_, max := f.NargsRange()
+	return max != 1
+}
+
 func (f Flag) IsOptarg() bool {
 	return f.NoOptDefVal != ""
 }
@@ -118,7 +195,7 @@ func (f Flag) Style() string {
 		return style.Carapace.FlagNoArg
 	case f.IsOptarg():
 		return style.Carapace.FlagOptArg
-	case f.Nargs() != 0:
+	case f.IsMultiArg():
 		return style.Carapace.FlagMultiArg
 	default:
 		return style.Carapace.FlagArg
@@ -154,6 +231,15 @@ func (f Flag) Definition() string {
 		}
 	case f.TakesValue():
 		definition += "="
+		switch min, max := f.NargsRange(); {
+		case min == 1 && max == 1:
+		case min == max:
+			definition += strconv.Itoa(min)
+		case max < 0:
+			definition += fmt.Sprintf("%v..", min)
+		default:
+			definition += fmt.Sprintf("%v..%v", min, max)
+		}
 	}
 
 	return definition
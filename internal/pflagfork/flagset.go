@@ -10,6 +10,7 @@ import (
 
 type FlagSet struct {
 	*pflag.FlagSet
+	Abbreviate bool // allow unambiguous prefixes of long flags (GNU getopt_long)
 }
 
 func (f FlagSet) IsPosix() bool {
@@ -21,6 +22,14 @@ func (f FlagSet) IsPosix() bool {
 	return true
 }
 
+// IsInterspersed returns false if flags are not parsed after the first positional argument (see pflag.FlagSet.SetInterspersed).
+func (f FlagSet) IsInterspersed() bool {
+	if field := reflect.ValueOf(f.FlagSet).Elem().FieldByName("interspersed"); field.IsValid() && field.Kind() == reflect.Bool {
+		return field.Bool()
+	}
+	return true
+}
+
 func (f FlagSet) IsShorthandSeries(arg string) bool {
 	re := regexp.MustCompile("^-(?P<shorthand>[^-=]+)")
 	return re.MatchString(arg) && f.IsPosix()
@@ -39,6 +48,66 @@ func (f FlagSet) IsMutuallyExclusive(flag *pflag.Flag) bool {
 	return false
 }
 
+// IsRequired checks if the flag is marked as required (`MarkFlagRequired`).
+func (f FlagSet) IsRequired(flag *pflag.Flag) bool {
+	required, ok := flag.Annotations["cobra_annotation_bash_completion_one_required_flag"]
+	return ok && len(required) > 0 && required[0] == "true"
+}
+
+// IsRequiredTogether checks if another flag of a `MarkFlagsRequiredTogether` group is set.
+func (f FlagSet) IsRequiredTogether(flag *pflag.Flag) bool {
+	for _, group := range flag.Annotations["cobra_annotation_required_if_others_set"] {
+		if f.changed(group) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOneRequired checks if none of the flags of a `MarkFlagsOneRequired` group is set.
+func (f FlagSet) IsOneRequired(flag *pflag.Flag) bool {
+	for _, group := range flag.Annotations["cobra_annotation_one_required"] {
+		if f.changed(group) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingRequired returns the flags still required (groups of flags where one is required are joined with `|`).
+func (f FlagSet) MissingRequired() []string {
+	missing := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			missing = append(missing, s)
+		}
+	}
+
+	f.FlagSet.VisitAll(func(flag *pflag.Flag) {
+		if !flag.Changed && (f.IsRequired(flag) || f.IsRequiredTogether(flag)) {
+			add("--" + flag.Name)
+		}
+		for _, group := range flag.Annotations["cobra_annotation_one_required"] {
+			if f.changed(group) == 0 {
+				add("--" + strings.Join(strings.Split(group, " "), "|--"))
+			}
+		}
+	})
+	return missing
+}
+
+// changed returns the amount of flags set within given group (space separated names).
+func (f FlagSet) changed(group string) (count int) {
+	for _, name := range strings.Split(group, " ") {
+		if other := f.Lookup(name); other != nil && other.Changed {
+			count++
+		}
+	}
+	return
+}
+
 func (f *FlagSet) VisitAll(fn func(*Flag)) {
 	f.FlagSet.VisitAll(func(flag *pflag.Flag) {
 		fn(&Flag{flag})
@@ -57,5 +126,118 @@ func (fs FlagSet) LookupArg(arg string) (result *Flag) {
 			result = f
 		}
 	})
+
+	if result == nil && fs.Abbreviate {
+		result, _ = fs.lookupAbbreviation(arg)
+	}
+	return
+}
+
+// lookupAbbreviation returns the flag uniquely identified by an abbreviated long flag (e.g. `--verb` for `--verbose`).
+func (fs FlagSet) lookupAbbreviation(arg string) (result *Flag, negated bool) {
+	if !strings.HasPrefix(arg, "--") || len(arg) == 2 {
+		return nil, false
+	}
+
+	matches := 0
+	fs.VisitAll(func(f *Flag) {
+		if f.Mode() != Default {
+			return
+		}
+
+		name := strings.SplitN(strings.TrimPrefix(arg, "--"), string(f.OptargDelimiter()), 2)[0]
+		switch {
+		case name == "":
+		case strings.HasPrefix(f.Name, name):
+			result, negated = f, false
+			matches++
+		case f.IsNegatable() && strings.HasPrefix("no-"+f.Name, name):
+			result, negated = f, true
+			matches++
+		}
+	})
+
+	if matches != 1 {
+		return nil, false // ambiguous
+	}
 	return
 }
+
+// Normalize returns the arg in a form the (unmodified) pflag parser understands.
+// Abbreviated long flags are expanded and negated ones (`--no-<name>`) set to false.
+func (fs FlagSet) Normalize(arg string) string {
+	if !strings.HasPrefix(arg, "--") {
+		return arg
+	}
+
+	f := fs.LookupArg(arg)
+	if f == nil || f.Mode() != Default {
+		return arg
+	}
+
+	delimiter := string(f.OptargDelimiter())
+	splitted := strings.SplitN(strings.TrimPrefix(arg, "--"), delimiter, 2)
+	switch {
+	case splitted[0] == f.Name:
+		return arg
+	case splitted[0] == "no-"+f.Name:
+		return "--" + f.Name + delimiter + "false"
+	}
+
+	if _, negated := fs.lookupAbbreviation(arg); negated {
+		return "--" + f.Name + delimiter + "false"
+	}
+	return "--" + f.Name + strings.TrimPrefix(arg, "--"+splitted[0])
+}
+
+// Reset restores default values (e.g. for reuse within a completion server).
+// Map values can't be cleared through their API, so keys of a previous parse remain unless overwritten by the default.
+func (fs FlagSet) Reset() {
+	fs.FlagSet.VisitAll(func(f *pflag.Flag) {
+		switch {
+		case strings.HasPrefix(f.Value.Type(), "stringTo"): // map values like `[a=b,c=d]`
+			if trimmed := strings.Trim(f.DefValue, "[]"); trimmed != "" {
+				_ = f.Value.Set(trimmed)
+			}
+		case isSliceValue(f.Value):
+			values := []string{}
+			if trimmed := strings.Trim(f.DefValue, "[]"); trimmed != "" {
+				values = strings.Split(trimmed, ",")
+			}
+			sv, ok := f.Value.(*resetSliceValue)
+			if !ok {
+				sv = &resetSliceValue{Value: f.Value, SliceValue: f.Value.(pflag.SliceValue)}
+				f.Value = sv
+			}
+			_ = sv.Replace(values)
+			sv.changed = false
+		default:
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	_ = fs.FlagSet.Parse([]string{"--"}) // clears remaining args as parsing no arguments is a noop (ArgsLenAtDash needs to be reset with Init)
+}
+
+func isSliceValue(v pflag.Value) bool {
+	_, ok := v.(pflag.SliceValue)
+	return ok
+}
+
+// resetSliceValue replaces the default on the first Set after a Reset.
+// pflag slice values only do so until they were changed once and append afterwards.
+type resetSliceValue struct {
+	pflag.Value
+	pflag.SliceValue
+	changed bool
+}
+
+func (v *resetSliceValue) Set(s string) error {
+	if !v.changed {
+		v.changed = true
+		if err := v.SliceValue.Replace([]string{}); err != nil {
+			return err
+		}
+	}
+	return v.Value.Set(s)
+}
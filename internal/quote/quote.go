@@ -0,0 +1,217 @@
+// Package quote provides shell-aware quoting of values.
+//
+// Shells that quote inserted values by themselves (bash-ble, clink, elvish, fish, yash) don't need it.
+package quote
+
+import (
+	"strings"
+
+	"github.com/rsteube/carapace/internal/env"
+)
+
+// State is the quote state of the word being completed.
+type State int
+
+const (
+	Unquoted State = iota // no quote opened
+	Single                // opened with a single quote
+	Double                // opened with a double quote
+	Escaped               // special characters escaped with a backslash
+)
+
+// StateOf returns the state for given opening quote (`'`, `"` or `\`).
+func StateOf(s string) State {
+	switch s {
+	case `'`:
+		return Single
+	case `"`:
+		return Double
+	case `\`:
+		return Escaped
+	default:
+		return Unquoted
+	}
+}
+
+// Current returns the quote state of the word being completed as passed by the snippet (`CARAPACE_QUOTE`).
+func Current() State {
+	return StateOf(env.Quote())
+}
+
+// Scan returns the quote state at the end of given (unparsed) command line.
+//
+//	Scan(`example 'it`)      // Single
+//	Scan(`example 'it'\''s`) // Single
+//	Scan(`example "a b" c`)  // Unquoted
+func Scan(line string) State {
+	state := Unquoted
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case state == Single:
+			if r == '\'' {
+				state = Unquoted
+			}
+		case r == '\\':
+			escaped = true
+		case state == Double:
+			if r == '"' {
+				state = Unquoted
+			}
+		case r == '\'':
+			state = Single
+		case r == '"':
+			state = Double
+		}
+	}
+	return state
+}
+
+// Quoting describes how a value is represented in a specific State.
+type Quoting struct {
+	Open    string            // opening quote
+	Close   string            // closing quote
+	Escape  *strings.Replacer // escapes characters within the quotes
+	Invalid string            // characters that can't be represented
+}
+
+// Rules are the quoting rules of a shell.
+type Rules struct {
+	Special  string            // characters that need quoting in an unquoted word
+	Default  State             // state used for unquoted words containing special characters
+	Keep     bool              // opening quote of the current word is kept by the shell (value continues after it)
+	Tilde    bool              // leading `~/` is left unquoted so that it is still expanded
+	Quotings map[State]Quoting // supported quotings
+}
+
+// Quote quotes value for the word being completed in given state.
+// The closing quote is omitted for partial values (e.g. `dir/`) so completion can continue.
+//
+//	Posix.Quote("it's", quote.Unquoted, false) // it\'s
+//	Posix.Quote("it's", quote.Double, false)   // "it's"
+func (r Rules) Quote(value string, state State, partial bool) string {
+	if r.Tilde && state == Unquoted && strings.HasPrefix(value, "~/") {
+		return "~/" + r.Quote(value[2:], state, partial)
+	}
+
+	preferred := state
+	if state == Unquoted {
+		if !strings.ContainsAny(value, r.Special) {
+			return value
+		}
+		preferred = r.Default
+	}
+
+	for _, s := range []State{preferred, Single, Double, Escaped} {
+		quoting, ok := r.Quotings[s]
+		if !ok || strings.ContainsAny(value, quoting.Invalid) {
+			continue
+		}
+
+		quoted := value
+		if quoting.Escape != nil {
+			quoted = quoting.Escape.Replace(value)
+		}
+		if !(r.Keep && s == state) {
+			quoted = quoting.Open + quoted
+		}
+		if !partial {
+			quoted = quoted + quoting.Close
+		}
+		return quoted
+	}
+	return value
+}
+
+func escaper(escape string, chars string) *strings.Replacer {
+	oldnew := make([]string, 0, len(chars)*2)
+	for _, c := range chars {
+		oldnew = append(oldnew, string(c), escape+string(c))
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+const posixSpecial = " \t&<>`'\"{}$#|?();[]*\\~"
+
+// Posix contains the quoting rules for bash, oil and zsh.
+var Posix = Rules{
+	Special: posixSpecial,
+	Default: Escaped,
+	Keep:    true,
+	Tilde:   true,
+	Quotings: map[State]Quoting{
+		Single:  {Open: `'`, Close: `'`, Escape: strings.NewReplacer(`'`, `'\''`)},
+		Double:  {Open: `"`, Close: `"`, Escape: escaper(`\`, "\\\"$`")},
+		Escaped: {Escape: escaper(`\`, posixSpecial)},
+	},
+}
+
+// Tcsh contains the quoting rules for tcsh.
+var Tcsh = Rules{
+	Special: " &<>`'\"{}$#|?();[]*\\",
+	Default: Escaped,
+	Keep:    true,
+	Tilde:   true,
+	Quotings: map[State]Quoting{
+		Single: {Open: `'`, Close: `'`, Escape: strings.NewReplacer(`'`, `'\''`)},
+		Escaped: {Invalid: `{}`, Escape: strings.NewReplacer( // escaping braces isn't working so these fall back to single quotes
+			`&`, `\&`, `<`, `\<`, `>`, `\>`, "`", "\\`", `'`, `\'`, `"`, `\"`, `$`, `\$`, `#`, `\#`,
+			`|`, `\|`, `?`, `\?`, `(`, `\(`, `)`, `\)`, `;`, `\;`, ` `, `\ `,
+			`[`, `\[`, `]`, `\]`, `*`, `\*`, `\`, `\\`,
+		)},
+	},
+}
+
+// Powershell contains the quoting rules for powershell.
+var Powershell = Rules{
+	Special: " \t{}()[]*$?\"'|<>&,;#@`",
+	Default: Single,
+	Quotings: map[State]Quoting{
+		Single:  {Open: `'`, Close: `'`, Escape: strings.NewReplacer(`'`, `''`)},
+		Double:  {Open: `"`, Close: `"`, Escape: escaper("`", "`\"$")},
+		Escaped: {Escape: escaper("`", " \t{}()[]*$?\"'|<>&,;#@`")},
+	},
+}
+
+// Nushell contains the quoting rules for nushell.
+var Nushell = Rules{
+	Special: " \t{}()[]<>$&\"'|;#\\`",
+	Default: Single,
+	Quotings: map[State]Quoting{
+		Single: {Open: `'`, Close: `'`, Invalid: `'`},
+		Double: {Open: `"`, Close: `"`, Escape: escaper(`\`, `\"`)},
+	},
+}
+
+// Python contains the quoting rules for xonsh.
+var Python = Rules{
+	Special: " \t()[]{}*$?\"'|<>&;#\\`",
+	Default: Single,
+	Quotings: map[State]Quoting{
+		Single: {Open: `'`, Close: `'`, Escape: escaper(`\`, `\'`)},
+		Double: {Open: `"`, Close: `"`, Escape: escaper(`\`, `\"`)},
+	},
+}
+
+// Ion contains the quoting rules for ion.
+var Ion = Rules{
+	Special: " \t'\"\\$@&|;<>(){}[]*?#",
+	Default: Single,
+	Quotings: map[State]Quoting{
+		Single:  {Open: `'`, Close: `'`, Invalid: `'`},
+		Double:  {Open: `"`, Close: `"`, Escape: escaper(`\`, `\"$@`)},
+		Escaped: {Escape: escaper(`\`, " \t'\"\\$@&|;<>(){}[]*?#")},
+	},
+}
+
+// Murex contains the quoting rules for murex.
+var Murex = Rules{
+	Special: " \t'\"()[]{}|;&<>$@#?*\\",
+	Default: Single,
+	Quotings: map[State]Quoting{
+		Single: {Open: `'`, Close: `'`, Invalid: `'`},
+		Double: {Open: `"`, Close: `"`, Escape: escaper(`\`, `\"$@`)},
+	},
+}
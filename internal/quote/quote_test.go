@@ -0,0 +1,80 @@
+package quote
+
+import (
+	"os"
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		rules    Rules
+		value    string
+		state    State
+		partial  bool
+		expected string
+	}{
+		{Posix, "plain", Unquoted, false, "plain"},
+		{Posix, "it's", Unquoted, false, `it\'s`},
+		{Posix, `a\"b`, Unquoted, false, `a\\\"b`},
+		{Posix, "it's", Single, false, `it'\''s'`},
+		{Posix, "it's", Double, false, `it's"`},
+		{Posix, `a\"b`, Double, false, `a\\\"b"`},
+		{Posix, "a b/", Double, true, `a b/`},
+		{Posix, "a b", Escaped, false, `a\ b`},
+		{Powershell, "it's", Unquoted, false, `'it''s'`},
+		{Powershell, `a"b`, Double, false, "\"a`\"b\""},
+		{Powershell, "a b/", Unquoted, true, `'a b/`},
+		{Nushell, "a b", Unquoted, false, `'a b'`},
+		{Nushell, "it's", Unquoted, false, `"it's"`},
+		{Nushell, "it's", Single, false, `"it's"`},
+		{Nushell, `a\"b`, Unquoted, false, `'a\"b'`},
+		{Python, `it's`, Unquoted, false, `'it\'s'`},
+		{Python, `a\"b`, Unquoted, false, `'a\\"b'`},
+		{Posix, "~/Documents/", Unquoted, true, `~/Documents/`},
+		{Posix, "~/My Documents/", Unquoted, true, `~/My\ Documents/`},
+		{Posix, "~user", Unquoted, false, `\~user`},
+		{Posix, "~/it's", Single, false, `~/it'\''s'`},
+		{Tcsh, "{a b}", Unquoted, false, `'{a b}'`},
+		{Tcsh, "a b", Unquoted, false, `a\ b`},
+		{Murex, "~/Documents/", Unquoted, true, `~/Documents/`},
+	}
+
+	for _, test := range tests {
+		if quoted := test.rules.Quote(test.value, test.state, test.partial); quoted != test.expected {
+			t.Errorf("expected %#v for %#v [state: %v, partial: %v]: %#v", test.expected, test.value, test.state, test.partial, quoted)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	for line, expected := range map[string]State{
+		`example it`:        Unquoted,
+		`example 'it`:       Single,
+		`example "it`:       Double,
+		`example 'it'\''s`:  Single,
+		`example "a\"b`:     Double,
+		`example "a b" c`:   Unquoted,
+		`example a\'b`:      Unquoted,
+		`example "it's" 'a`: Single,
+	} {
+		if state := Scan(line); state != expected {
+			t.Errorf("expected %v for %#v: %v", expected, line, state)
+		}
+	}
+}
+
+func TestCurrent(t *testing.T) {
+	for value, expected := range map[string]State{
+		"":   Unquoted,
+		`'`:  Single,
+		`"`:  Double,
+		`\`:  Escaped,
+		`$'`: Unquoted,
+	} {
+		os.Setenv("CARAPACE_QUOTE", value)
+		if state := Current(); state != expected {
+			t.Errorf("expected %v for %#v: %v", expected, value, state)
+		}
+	}
+	os.Unsetenv("CARAPACE_QUOTE")
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// PrepareDir creates the directory of the socket and verifies it is only accessible by the current user.
+func PrepareDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("socket directory is not a directory: %v", dir)
+	}
+	return verifyOwner(info)
+}
+
+// Authorize returns an error if the peer of given connection is not the current user.
+// Platforms without support for peer credentials rely on the permissions of the socket directory.
+func Authorize(conn net.Conn) error {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return errors.New("not a unix socket connection")
+	}
+
+	uid, supported, err := peerUid(unixConn)
+	switch {
+	case err != nil:
+		return err
+	case supported && uid != os.Getuid():
+		return fmt.Errorf("peer uid %v does not match %v", uid, os.Getuid())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func verifyOwner(info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("failed to determine owner of %v", info.Name())
+	}
+	if int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("socket directory is owned by uid %v", stat.Uid)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("socket directory is accessible by other users: %v", info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package server
+
+import "os"
+
+func verifyOwner(info os.FileInfo) error {
+	return nil // access is restricted by the ACL of the user specific runtime directory
+}
@@ -0,0 +1,25 @@
+package server
+
+import (
+	"net"
+	"syscall"
+)
+
+func peerUid(conn *net.UnixConn) (uid int, supported bool, err error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, true, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return 0, true, err
+	}
+	if credErr != nil {
+		return 0, true, credErr
+	}
+	return int(cred.Uid), true, nil
+}
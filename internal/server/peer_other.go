@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package server
+
+import "net"
+
+func peerUid(conn *net.UnixConn) (uid int, supported bool, err error) {
+	return 0, false, nil // getpeereid is not available in syscall
+}
@@ -0,0 +1,96 @@
+// Package server provides the protocol for the completion server
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/env"
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/pkg/xdg"
+)
+
+// Socket returns the path of the unix socket for the current executable.
+func Socket() string {
+	return fmt.Sprintf("%v/carapace/%v.sock", xdg.UserRuntimeDir(), uid.Executable())
+}
+
+// Request contains the arguments, environment and working directory of a `_carapace` invocation.
+//
+// It is encoded as NUL terminated fields: the working directory, the environment variables,
+// an empty field as separator and the arguments.
+type Request struct {
+	Dir  string
+	Env  []string
+	Args []string
+}
+
+// ParseRequest decodes a request.
+func ParseRequest(b []byte) (r Request, err error) {
+	if !bytes.HasSuffix(b, []byte{0}) {
+		return r, errors.New("malformed request: missing NUL terminator")
+	}
+
+	fields := bytes.Split(bytes.TrimSuffix(b, []byte{0}), []byte{0})
+	r.Dir = string(fields[0])
+	r.Env = make([]string, 0)
+	r.Args = make([]string, 0)
+
+	separated := false
+	for _, field := range fields[1:] {
+		switch {
+		case separated:
+			r.Args = append(r.Args, string(field))
+		case len(field) == 0:
+			separated = true
+		default:
+			r.Env = append(r.Env, string(field))
+		}
+	}
+
+	if !separated {
+		return r, errors.New("malformed request: missing separator")
+	}
+	return r, nil
+}
+
+// Bytes encodes the request.
+func (r Request) Bytes() []byte {
+	var b bytes.Buffer
+	b.WriteString(r.Dir)
+	b.WriteByte(0)
+	for _, e := range r.Env {
+		b.WriteString(e)
+		b.WriteByte(0)
+	}
+	b.WriteByte(0)
+	for _, arg := range r.Args {
+		b.WriteString(arg)
+		b.WriteByte(0)
+	}
+	return b.Bytes()
+}
+
+// Client returns the command used by snippets to invoke `_carapace`.
+//
+// Forwarding to the completion server is opt-in (`CARAPACE_SERVER` set during snippet generation)
+// as the wrapper adds a `sh` invocation to every completion and requires `socat` and `env -0`.
+// It falls back to invoking `_carapace` on the executable if the server isn't available.
+// The socket path is resolved at runtime as in Socket.
+//
+//	xargs $(Client()) bash ...
+func Client() string {
+	executable := uid.Executable()
+	if !env.Server() || strings.ContainsAny(executable, `'\`) {
+		return fmt.Sprintf("%v _carapace", executable)
+	}
+
+	script := `s="${XDG_RUNTIME_DIR:-${TMPDIR:-/tmp}/carapace-$(id -u)}/carapace/$0.sock";` +
+		` if [ -S "$s" ] && command -v socat >/dev/null 2>&1 && env -0 >/dev/null 2>&1; then` +
+		` { printf "%s\0" "$PWD"; env -0; printf "\0"; printf "%s\0" "$@"; } | socat -t30 - "UNIX-CONNECT:$s" && exit;` +
+		` fi;` +
+		` exec "$0" _carapace "$@"`
+	return fmt.Sprintf(`sh -c '%v' '%v'`, script, executable) // executable is passed as `$0` to avoid quoting it within the script
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/rsteube/carapace/internal/uid"
+)
+
+func TestRequest(t *testing.T) {
+	expected := Request{
+		Dir:  "/tmp",
+		Env:  []string{"A=1", "B="},
+		Args: []string{"bash", "_", "example", ""},
+	}
+
+	actual, err := ParseRequest(expected.Bytes())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %#v was %#v", expected, actual)
+	}
+
+	if _, err := ParseRequest([]byte("/tmp\x00A=1\x00")); err == nil {
+		t.Error("should fail on missing separator")
+	}
+	if _, err := ParseRequest([]byte("/tmp")); err == nil {
+		t.Error("should fail on missing terminator")
+	}
+}
+
+func TestPrepareDir(t *testing.T) {
+	dir := t.TempDir() + "/carapace"
+	if err := PrepareDir(dir); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := PrepareDir(dir); err == nil {
+		t.Error("should fail on directory accessible by other users")
+	}
+
+	if err := os.Symlink(t.TempDir(), dir+"/link"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := PrepareDir(dir + "/link"); err == nil {
+		t.Error("should fail on symlink")
+	}
+}
+
+func TestClient(t *testing.T) {
+	defer os.Setenv("CARAPACE_SERVER", os.Getenv("CARAPACE_SERVER"))
+
+	os.Unsetenv("CARAPACE_SERVER")
+	if client := Client(); client != uid.Executable()+" _carapace" {
+		t.Errorf("should invoke executable directly by default: %v", client)
+	}
+
+	os.Setenv("CARAPACE_SERVER", "1")
+	if client := Client(); !strings.HasPrefix(client, "sh -c '") || !strings.HasSuffix(client, "' '"+uid.Executable()+"'") {
+		t.Errorf("should forward to server if enabled: %v", client)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -14,30 +15,6 @@ var sanitizer = strings.NewReplacer(
 	"\t", ``,
 )
 
-var quoter = strings.NewReplacer(
-	// seems readline provides quotation only for the filename completion (which would add suffixes) so do that here
-	`&`, `\&`,
-	`<`, `\<`,
-	`>`, `\>`,
-	"`", "\\`",
-	`'`, `\'`,
-	`"`, `\"`,
-	`{`, `\{`,
-	`}`, `\}`,
-	`$`, `\$`,
-	`#`, `\#`,
-	`|`, `\|`,
-	`?`, `\?`,
-	`(`, `\(`,
-	`)`, `\)`,
-	`;`, `\;`,
-	` `, `\ `,
-	`[`, `\[`,
-	`]`, `\]`,
-	`*`, `\*`,
-	`\`, `\\`,
-)
-
 func commonPrefix(a, b string) string {
 	i := 0
 	for i < len(a) && i < len(b) && a[i] == b[i] {
@@ -98,9 +75,10 @@ func ActionRawValues(currentWord string, meta common.Meta, values common.RawValu
 	vals := make([]string, len(values))
 	for index, val := range values {
 		if len(values) == 1 {
-			vals[index] = quoter.Replace(sanitizer.Replace(val.Value))
+			// seems readline provides quotation only for the filename completion (which would add suffixes) so do that here
+			vals[index] = quote.Posix.Quote(sanitizer.Replace(val.Value), quote.Current(), meta.Nospace.Matches(val.Value))
 			if !meta.Nospace.Matches(val.Value) {
-				vals[index] = val.Value + " "
+				vals[index] = vals[index] + " "
 			}
 
 		} else {
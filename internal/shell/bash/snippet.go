@@ -4,7 +4,7 @@ package bash
 import (
 	"fmt"
 
-	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/spf13/cobra"
 )
 
@@ -16,14 +16,14 @@ _%v_completion() {
 
   local compline="${COMP_LINE:0:${COMP_POINT}}"
   local IFS=$'\n'
-  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs %v _carapace bash)
+  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | CARAPACE_QUOTE="${COMP_WORDS[COMP_CWORD]:0:1}" xargs %v bash)
   [[ "${COMPREPLY[*]}" == "" ]] && COMPREPLY=() # fix for mapfile creating a non-empty array from empty command output
 
   compopt -o nospace
 }
 
 complete -F _%v_completion %v
-`, cmd.Name(), uid.Executable(), cmd.Name(), cmd.Name())
+`, cmd.Name(), server.Client(), cmd.Name(), cmd.Name())
 
 	return result
 }
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/rsteube/carapace/internal/shell/bash"
-	"github.com/rsteube/carapace/internal/uid"
 	"github.com/spf13/cobra"
 )
 
@@ -26,7 +26,7 @@ _%v_completion_ble() {
     local compline="${COMP_LINE:0:${COMP_POINT}}"
     local IFS=$'\n'
     local c
-    mapfile -t c < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs %v _carapace bash-ble)
+    mapfile -t c < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs %v bash-ble)
     [[ "${c[*]}" == "" ]] && c=() # fix for mapfile creating a non-empty array from empty command output
 
     local cand
@@ -39,7 +39,7 @@ _%v_completion_ble() {
 }
 
 complete -F _%v_completion_ble %v
-`, cmd.Name(), uid.Executable(), cmd.Name(), cmd.Name(), cmd.Name(), cmd.Name())
+`, cmd.Name(), server.Client(), cmd.Name(), cmd.Name(), cmd.Name(), cmd.Name())
 
 	return bashSnippet + result
 }
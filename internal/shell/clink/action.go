@@ -0,0 +1,38 @@
+package clink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/pkg/style"
+	"github.com/rsteube/carapace/third_party/github.com/elves/elvish/pkg/ui"
+)
+
+var sanitizer = strings.NewReplacer(
+	"\n", ``,
+	"\r", ``,
+	"\t", ``,
+)
+
+// ActionRawValues formats values for clink.
+func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	valueStyle := style.Default
+	if s := style.Carapace.Value; s != "" && ui.ParseStyling(s) != nil {
+		valueStyle = s
+	}
+
+	vals := make([]string, len(values))
+	for index, val := range values {
+		if val.Style == "" || ui.ParseStyling(val.Style) == nil {
+			val.Style = valueStyle
+		}
+
+		display := sanitizer.Replace(val.Display)
+		if sgr := style.SGR(val.Style); sgr != "" {
+			display = fmt.Sprintf("\x1b[%vm%v\x1b[0m", sgr, display)
+		}
+		vals[index] = fmt.Sprintf("%v\t%v\t%v\t%v", sanitizer.Replace(val.Value), display, sanitizer.Replace(val.TrimmedDescription()), meta.Nospace.Matches(val.Value))
+	}
+	return strings.Join(vals, "\n")
+}
@@ -0,0 +1,59 @@
+package clink
+
+import (
+	"testing"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/pkg/style"
+)
+
+func TestActionRawValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   common.RawValues
+		messages []string
+		nospace  rune
+		expected string
+	}{
+		{
+			name:     "fields are separated by tabs so these are removed",
+			values:   common.RawValues{{Value: "a\tb", Display: "a\tb", Description: "tab\tdescription"}},
+			expected: "ab\tab\ttabdescription\tfalse",
+		},
+		{
+			name:     "values are passed unquoted as clink quotes them",
+			values:   common.RawValues{{Value: `a b&"c"`, Display: `a b&"c"`}},
+			expected: "a b&\"c\"\ta b&\"c\"\t\tfalse",
+		},
+		{
+			name:     "empty description keeps its field",
+			values:   common.RawValues{{Value: "plain", Display: "plain"}, {Value: "described", Display: "described", Description: "description"}},
+			expected: "plain\tplain\t\tfalse\ndescribed\tdescribed\tdescription\tfalse",
+		},
+		{
+			name:     "style is only applied to the display",
+			values:   common.RawValues{{Value: "dir/", Display: "dir/", Style: style.Of(style.Blue, style.Bold)}},
+			nospace:  '/',
+			expected: "dir/\t\x1b[1;34mdir/\x1b[0m\t\ttrue",
+		},
+		{
+			name:     "messages",
+			messages: []string{"some error"},
+			expected: "ERR\t\x1b[1;31mERR\x1b[0m\tsome error\tfalse\n_\t_\t\tfalse",
+		},
+	}
+
+	for _, test := range tests {
+		meta := common.Meta{}
+		if test.nospace != 0 {
+			meta.Nospace.Add(test.nospace)
+		}
+		for _, message := range test.messages {
+			meta.Messages.Add(message)
+		}
+		values := meta.Messages.Integrate(test.values, "")
+		if s := ActionRawValues("", meta, values); s != test.expected {
+			t.Errorf("%v: expected %#v, got %#v", test.name, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package clink
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestSnippet(t *testing.T) {
+	s := Snippet(&cobra.Command{Use: "my-cmd"})
+	if !strings.Contains(s, "local _my__cmd_generator = clink.generator(1)") {
+		t.Errorf("missing generator: %v", s)
+	}
+	if !strings.Contains(s, "path.getbasename(line_state:getword(1)) ~= 'my-cmd'") {
+		t.Errorf("missing command check: %v", s)
+	}
+	if !strings.Contains(s, "os.setenv('CARAPACE_CLINK_WORDS', table.concat(words, '\\n'))") {
+		t.Errorf("missing words: %v", s)
+	}
+	if !strings.Contains(s, " _carapace clink')") {
+		t.Errorf("words must not be passed through cmd.exe: %v", s)
+	}
+	if strings.Contains(s, "%!") {
+		t.Errorf("malformed format: %v", s)
+	}
+}
@@ -0,0 +1,48 @@
+// Package cobra provides the output format of cobra's `__complete` command
+package cobra
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/spf13/cobra"
+)
+
+var sanitizer = strings.NewReplacer(
+	"\n", ``,
+	"\r", ``,
+	"\t", ``,
+)
+
+// ActionRawValues formats values for cobra (`__complete`).
+// ActiveHelp is disabled if activeHelpConfig (see cobra.GetActiveHelpConfig) is `0`.
+//
+//	value\tdescription
+//	_activeHelp_ usage
+//	:directive
+func ActionRawValues(currentWord string, activeHelpConfig string, meta common.Meta, values common.RawValues) string {
+	lines := make([]string, 0, len(values)+2)
+	directive := cobra.ShellCompDirectiveNoFileComp
+	for _, val := range values {
+		value := sanitizer.Replace(val.Value)
+		if meta.Nospace.Matches(val.Value) {
+			directive |= cobra.ShellCompDirectiveNoSpace
+		}
+
+		if description := sanitizer.Replace(val.TrimmedDescription()); description != "" {
+			value = fmt.Sprintf("%v\t%v", value, description)
+		}
+		lines = append(lines, value)
+	}
+
+	if activeHelpConfig != "0" { // usage and messages are shown as ActiveHelp
+		if meta.Usage != "" {
+			lines = cobra.AppendActiveHelp(lines, sanitizer.Replace(meta.Usage))
+		}
+		for _, message := range meta.Messages.Get() {
+			lines = cobra.AppendActiveHelp(lines, sanitizer.Replace(message))
+		}
+	}
+	return strings.Join(append(lines, fmt.Sprintf(":%d", directive)), "\n")
+}
@@ -14,9 +14,16 @@ var sanitizer = strings.NewReplacer(
 
 // ActionRawValues formats values for fish.
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
-	vals := make([]string, len(values))
-	for index, val := range values {
-		vals[index] = fmt.Sprintf("%v\t%v", sanitizer.Replace(val.Value), sanitizer.Replace(val.TrimmedDescription()))
-	}
+	tagged := values.Tagged()
+	vals := make([]string, 0, len(values))
+	values.EachTag(func(tag string, values common.RawValues) {
+		for _, val := range values {
+			description := val.TrimmedDescription()
+			if tagged {
+				description = val.TaggedDescription() // prefix description with tag to distinguish groups
+			}
+			vals = append(vals, fmt.Sprintf("%v\t%v", sanitizer.Replace(val.Value), sanitizer.Replace(description)))
+		}
+	})
 	return strings.Join(vals, "\n")
 }
@@ -4,7 +4,7 @@ package fish
 import (
 	"fmt"
 
-	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/spf13/cobra"
 )
 
@@ -23,10 +23,10 @@ func Snippet(cmd *cobra.Command) string {
 end
 
 function _%v_callback
-  commandline -cp | sed "s/\$/"(_%v_quote_suffix)"/" | sed "s/ \$/ ''/" | xargs %v _carapace fish
+  commandline -cp | sed "s/\$/"(_%v_quote_suffix)"/" | sed "s/ \$/ ''/" | xargs %v fish
 end
 
 complete -c %v -f
 complete -c '%v' -f -a '(_%v_callback)' -r
-`, cmd.Name(), cmd.Name(), cmd.Name(), uid.Executable(), cmd.Name(), cmd.Name(), cmd.Name())
+`, cmd.Name(), cmd.Name(), cmd.Name(), server.Client(), cmd.Name(), cmd.Name(), cmd.Name())
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -29,9 +30,12 @@ type suggestion struct {
 
 // ActionRawValues formats values for ion.
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	state := quote.Current()
 	vals := make([]suggestion, len(values))
 	for index, val := range sanitize(values) {
-		if !meta.Nospace.Matches(val.Value) {
+		nospace := meta.Nospace.Matches(val.Value)
+		val.Value = quote.Ion.Quote(val.Value, state, nospace)
+		if !nospace {
 			val.Value = val.Value + " "
 		}
 
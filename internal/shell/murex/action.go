@@ -0,0 +1,31 @@
+package murex
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
+)
+
+var sanitizer = strings.NewReplacer(
+	"\n", ``,
+	"\r", ``,
+	"\t", ``,
+)
+
+// ActionRawValues formats values for murex.
+func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	state := quote.Current()
+	vals := make(map[string]string, len(values))
+	for _, val := range values {
+		nospace := meta.Nospace.Matches(val.Value)
+		val.Value = quote.Murex.Quote(sanitizer.Replace(val.Value), state, nospace)
+		if !nospace {
+			val.Value = val.Value + " "
+		}
+		vals[val.Value] = sanitizer.Replace(val.TrimmedDescription())
+	}
+	m, _ := json.Marshal(vals)
+	return string(m)
+}
@@ -0,0 +1,68 @@
+package murex
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rsteube/carapace/internal/common"
+)
+
+func TestActionRawValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		quote    string
+		values   common.RawValues
+		messages []string
+		nospace  rune
+		expected string
+	}{
+		{
+			name:     "special characters are single quoted",
+			values:   common.RawValues{{Value: "a b", Display: "a b"}, {Value: "$var", Display: "$var"}},
+			expected: `{"'$var' ":"","'a b' ":""}`,
+		},
+		{
+			name:     "single quotes can't be escaped so these are double quoted",
+			values:   common.RawValues{{Value: "it's", Display: "it's"}},
+			expected: `{"\"it's\" ":""}`,
+		},
+		{
+			name:     "opening double quote is continued",
+			quote:    `"`,
+			values:   common.RawValues{{Value: `a"b`, Display: `a"b`}},
+			expected: `{"\"a\\\"b\" ":""}`,
+		},
+		{
+			name:     "leading tilde is not quoted",
+			values:   common.RawValues{{Value: "~/dir/", Display: "~/dir/"}},
+			nospace:  '/',
+			expected: `{"~/dir/":""}`,
+		},
+		{
+			name:     "empty and multiline descriptions",
+			values:   common.RawValues{{Value: "plain", Display: "plain"}, {Value: "described", Display: "described", Description: "first\nsecond"}},
+			expected: `{"described ":"first","plain ":""}`,
+		},
+		{
+			name:     "messages",
+			messages: []string{"some error"},
+			expected: `{"ERR ":"some error","_ ":""}`,
+		},
+	}
+
+	for _, test := range tests {
+		os.Setenv("CARAPACE_QUOTE", test.quote)
+		meta := common.Meta{}
+		if test.nospace != 0 {
+			meta.Nospace.Add(test.nospace)
+		}
+		for _, message := range test.messages {
+			meta.Messages.Add(message)
+		}
+		values := meta.Messages.Integrate(test.values, "")
+		if s := ActionRawValues("", meta, values); s != test.expected {
+			t.Errorf("%v: expected %#v, got %#v", test.name, test.expected, s)
+		}
+	}
+	os.Unsetenv("CARAPACE_QUOTE")
+}
@@ -0,0 +1,21 @@
+// Package murex provides murex completion
+package murex
+
+import (
+	"fmt"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/spf13/cobra"
+)
+
+// Snippet creates the murex completion script.
+func Snippet(cmd *cobra.Command) string {
+	return fmt.Sprintf(`autocomplete set %v { [{
+  "DynamicDesc": ({
+    %v _carapace murex @ARGS
+  }),
+  "AllowAny": true,
+  "ListView": true
+}] }
+`, cmd.Name(), uid.Executable())
+}
@@ -3,14 +3,99 @@ package nushell
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
+	"github.com/rsteube/carapace/pkg/style"
+	"github.com/rsteube/carapace/third_party/github.com/elves/elvish/pkg/ui"
 )
 
 type record struct {
-	Value       string `json:"value"`
-	Description string `json:"description,omitempty"`
+	Value       string       `json:"value"`
+	Description string       `json:"description,omitempty"`
+	Style       *recordStyle `json:"style,omitempty"`
+}
+
+type recordStyle struct {
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Attributes string `json:"attr,omitempty"`
+}
+
+// convertColor converts a color to its nushell representation (`red`, `light_red`, `#ff0000`).
+func convertColor(c ui.Color) string {
+	if c == nil {
+		return ""
+	}
+	switch s := c.String(); {
+	case strings.HasPrefix(s, "#"):
+		return s
+	case strings.HasPrefix(s, "bright-"):
+		return "light_" + strings.TrimPrefix(s, "bright-")
+	case strings.HasPrefix(s, "color"):
+		index, _ := strconv.Atoi(strings.TrimPrefix(s, "color"))
+		return xterm256Color(index)
+	default:
+		return s
+	}
+}
+
+// xterm256Color converts a color of the xterm 256-color palette (the first 16 are named ones).
+func xterm256Color(index int) string {
+	names := []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+	switch {
+	case index < 8:
+		return names[index]
+	case index < 16:
+		return "light_" + names[index-8]
+	case index < 232: // 6x6x6 color cube
+		levels := []int{0, 95, 135, 175, 215, 255}
+		index -= 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[index/36], levels[index/6%6], levels[index%6])
+	default: // grayscale ramp
+		level := 8 + (index-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", level, level, level)
+	}
+}
+
+// convertStyle converts a carapace style to a nushell style record.
+func convertStyle(s string) *recordStyle {
+	stylings := make([]ui.Styling, 0)
+	for _, word := range strings.Split(s, " ") {
+		if styling := ui.ParseStyling(word); styling != nil {
+			stylings = append(stylings, styling)
+		}
+	}
+	parsed := ui.ApplyStyling(ui.Style{}, stylings...)
+
+	attributes := ""
+	for _, attribute := range []struct {
+		enabled bool
+		code    string
+	}{
+		{parsed.Bold, "b"},
+		{parsed.Dim, "d"},
+		{parsed.Italic, "i"},
+		{parsed.Underlined, "u"},
+		{parsed.Blink, "l"},
+		{parsed.Inverse, "r"},
+	} {
+		if attribute.enabled {
+			attributes += attribute.code
+		}
+	}
+
+	converted := recordStyle{
+		Foreground: convertColor(parsed.Foreground),
+		Background: convertColor(parsed.Background),
+		Attributes: attributes,
+	}
+	if converted == (recordStyle{}) {
+		return nil
+	}
+	return &converted
 }
 
 var sanitizer = strings.NewReplacer(
@@ -29,17 +114,20 @@ func sanitize(values []common.RawValue) []common.RawValue {
 
 // ActionRawValues formats values for nushell.
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	state := quote.Current()
 	vals := make([]record, len(values))
 	for index, val := range sanitize(values) {
-		if strings.ContainsAny(val.Value, ` {}()[]<>$&"|;#\`+"`") {
-			val.Value = fmt.Sprintf("'%v'", val.Value)
+		nospace := meta.Nospace.Matches(val.Value)
+		val.Value = quote.Nushell.Quote(val.Value, state, nospace)
+		if !nospace {
+			val.Value = val.Value + " "
 		}
 
-		if !meta.Nospace.Matches(val.Value) {
-			val.Value = val.Value + " "
+		if val.Style == "" {
+			val.Style = style.Carapace.Value
 		}
 
-		vals[index] = record{Value: val.Value, Description: val.TrimmedDescription()}
+		vals[index] = record{Value: val.Value, Description: val.TrimmedDescription(), Style: convertStyle(val.Style)}
 	}
 	m, _ := json.Marshal(vals)
 	return string(m)
@@ -0,0 +1,49 @@
+package nushell
+
+import (
+	"testing"
+
+	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/pkg/style"
+)
+
+func TestActionRawValues(t *testing.T) {
+	meta := common.Meta{}
+	meta.Nospace.Add('/')
+
+	values := common.RawValues{
+		{Value: "dir/", Display: "dir/", Style: style.Of(style.BrightBlue, style.Bold, style.Underlined)},
+		{Value: "first", Display: "first", Description: "first value", Style: style.Of(style.Red, style.BgBlack)},
+		{Value: "plain", Display: "plain"},
+	}
+
+	expected := `[{"value":"dir/","style":{"fg":"light_blue","attr":"bu"}},` +
+		`{"value":"first ","description":"first value","style":{"fg":"red","bg":"black"}},` +
+		`{"value":"plain "}]`
+	if s := ActionRawValues("", meta, values); s != expected {
+		t.Errorf("expected %#v, got %#v", expected, s)
+	}
+}
+
+func TestConvertStyle(t *testing.T) {
+	if s := convertStyle("#ff0000 bg-bright-white italic"); *s != (recordStyle{Foreground: "#ff0000", Background: "light_white", Attributes: "i"}) {
+		t.Errorf("unexpected style: %#v", s)
+	}
+	if s := convertStyle("color1 bg-color202"); *s != (recordStyle{Foreground: "red", Background: "#ff5f00"}) {
+		t.Errorf("unexpected xterm256 style: %#v", s)
+	}
+	for color, expected := range map[string]string{
+		"color9":   "light_red",
+		"color16":  "#000000",
+		"color231": "#ffffff",
+		"color232": "#080808",
+		"color255": "#eeeeee",
+	} {
+		if s := convertStyle(color); s == nil || s.Foreground != expected {
+			t.Errorf("expected %v for %v: %#v", expected, color, s)
+		}
+	}
+	if s := convertStyle(style.Default); s != nil {
+		t.Errorf("default style should be omitted: %#v", s)
+	}
+}
@@ -13,16 +13,10 @@ func Snippet(cmd *cobra.Command) string {
 	return fmt.Sprintf(`let external_completer = {|spans| 
   {
     $spans.0: { } # default
-    %v: { %v _carapace nushell $spans | from json }
+    %v: { with-env {CARAPACE_QUOTE: ($spans | last | str replace --regex "^(['\"]?).*" '$1')} { %v _carapace nushell $spans | from json } }
   } | get $spans.0 | each {|it| do $it}
 }
 
-let-env config = {
-  completions: {
-    external: {
-      enable: true
-      completer: $external_completer
-    }
-  }
-}`, cmd.Name(), uid.Executable())
+$env.config.completions.external.enable = true
+$env.config.completions.external.completer = $external_completer`, cmd.Name(), uid.Executable())
 }
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -18,13 +19,16 @@ const nospaceIndicator = "\001"
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
 	vals := make([]string, len(values))
 	for index, val := range values {
-		if meta.Nospace.Matches(val.Value) {
+		nospace := meta.Nospace.Matches(val.Value)
+		if len(values) == 1 {
+			val.Value = quote.Posix.Quote(sanitizer.Replace(val.Value), quote.Current(), nospace)
+		}
+		if nospace {
 			val.Value = val.Value + nospaceIndicator
 		}
 
 		if len(values) == 1 {
-			formattedVal := sanitizer.Replace(val.Value)
-			vals[index] = formattedVal
+			vals[index] = val.Value
 		} else {
 			if val.Description != "" {
 				vals[index] = fmt.Sprintf("%v (%v)", val.Value, sanitizer.Replace(val.TrimmedDescription()))
@@ -4,7 +4,7 @@ package oil
 import (
 	"fmt"
 
-	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/spf13/cobra"
 )
 
@@ -14,7 +14,7 @@ func Snippet(cmd *cobra.Command) string {
 _%v_completion() {
   local compline="${COMP_LINE:0:${COMP_POINT}}"
   local IFS=$'\n'
-  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | xargs %v _carapace oil)
+  mapfile -t COMPREPLY < <(echo "$compline" | sed -e "s/ \$/ ''/" -e 's/"/\"/g' | CARAPACE_QUOTE="${COMP_WORDS[COMP_CWORD]:0:1}" xargs %v oil)
   [[ "${COMPREPLY[@]}" == "" ]] && COMPREPLY=() # fix for mapfile creating a non-empty array from empty command output
   [[ ${COMPREPLY[0]} == *[/=@:.,$'\001'] ]] && compopt -o nospace
   # TODO use mapfile
@@ -23,7 +23,7 @@ _%v_completion() {
 }
 
 complete -F _%v_completion %v
-`, cmd.Name(), uid.Executable(), cmd.Name(), cmd.Name())
+`, cmd.Name(), server.Client(), cmd.Name(), cmd.Name())
 
 	return result
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 	"github.com/rsteube/carapace/pkg/style"
 	"github.com/rsteube/carapace/third_party/github.com/elves/elvish/pkg/ui"
 )
@@ -41,36 +42,41 @@ func ActionRawValues(currentWord string, meta common.Meta, values common.RawValu
 		descriptionStyle = s
 	}
 
+	state := quote.Current()
+	tagged := values.Tagged()
 	vals := make([]completionResult, 0, len(values))
-	for _, val := range values {
-		if val.Value != "" { // must not be empty - any empty `''` parameter in CompletionResult causes an error
-			val.Value = sanitizer.Replace(val.Value)
+	values.EachTag(func(tag string, values common.RawValues) {
+		for _, val := range values {
+			if val.Value != "" { // must not be empty - any empty `''` parameter in CompletionResult causes an error
+				nospace := meta.Nospace.Matches(val.Value)
+				val.Value = quote.Powershell.Quote(sanitizer.Replace(val.Value), state, nospace)
+				if !nospace {
+					val.Value = val.Value + " "
+				}
 
-			if strings.ContainsAny(val.Value, ` {}()[]*$?\"|<>&(),;#`+"`") {
-				val.Value = fmt.Sprintf("'%v'", val.Value)
-			}
+				if val.Style == "" || ui.ParseStyling(val.Style) == nil {
+					val.Style = valueStyle
+				}
 
-			if !meta.Nospace.Matches(val.Value) {
-				val.Value = val.Value + " "
-			}
+				listItemText := fmt.Sprintf("`e[21;22;23;24;25;29m`e[%vm%v`e[21;22;23;24;25;29;39;49m", sgr(val.Style), sanitizer.Replace(val.Display))
+				if val.Description != "" {
+					listItemText = listItemText + fmt.Sprintf("`e[%vm `e[%vm(%v)`e[21;22;23;24;25;29;39;49m", sgr(descriptionStyle+" bg-default"), sgr(descriptionStyle), sanitizer.Replace(val.TrimmedDescription()))
+				}
+				listItemText = listItemText + "`e[0m"
 
-			if val.Style == "" || ui.ParseStyling(val.Style) == nil {
-				val.Style = valueStyle
-			}
+				toolTip := " "
+				if tagged {
+					toolTip = sanitizer.Replace(val.TaggedDescription()) // encode tag in tooltip to distinguish groups
+				}
 
-			listItemText := fmt.Sprintf("`e[21;22;23;24;25;29m`e[%vm%v`e[21;22;23;24;25;29;39;49m", sgr(val.Style), sanitizer.Replace(val.Display))
-			if val.Description != "" {
-				listItemText = listItemText + fmt.Sprintf("`e[%vm `e[%vm(%v)`e[21;22;23;24;25;29;39;49m", sgr(descriptionStyle+" bg-default"), sgr(descriptionStyle), sanitizer.Replace(val.TrimmedDescription()))
+				vals = append(vals, completionResult{
+					CompletionText: val.Value,
+					ListItemText:   ensureNotEmpty(listItemText),
+					ToolTip:        ensureNotEmpty(toolTip),
+				})
 			}
-			listItemText = listItemText + "`e[0m"
-
-			vals = append(vals, completionResult{
-				CompletionText: val.Value,
-				ListItemText:   ensureNotEmpty(listItemText),
-				ToolTip:        ensureNotEmpty(" "),
-			})
 		}
-	}
+	})
 	m, _ := json.Marshal(vals)
 	return string(m)
 }
@@ -40,6 +40,11 @@ Function _%v_completer {
       $elems += $t.replace('`+"`"+`,', ',') # quick fix
     }
 
+    $env:CARAPACE_QUOTE = ''
+    if ($wordToComplete -match '^[''"]') {
+      $env:CARAPACE_QUOTE = $wordToComplete.Substring(0,1)
+    }
+
     $completions = @(
       if (!$wordToComplete) {
         %v _carapace powershell $($elems| ForEach-Object {$_}) '' | ConvertFrom-Json | ForEach-Object { [CompletionResult]::new($_.CompletionText, $_.ListItemText.replace('`+"`"+`e[', "`+"`"+`e["), [CompletionResultType]::ParameterValue, $_.ToolTip) }
@@ -47,6 +52,7 @@ Function _%v_completer {
         %v _carapace powershell $($elems| ForEach-Object {$_}) | ConvertFrom-Json | ForEach-Object { [CompletionResult]::new($_.CompletionText, $_.ListItemText.replace('`+"`"+`e[', "`+"`"+`e["), [CompletionResultType]::ParameterValue, $_.ToolTip) }
       }
     )
+    $env:CARAPACE_QUOTE = $null
 
     if ($completions.count -eq 0) {
       return "" # prevent default file completion
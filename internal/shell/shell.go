@@ -9,16 +9,20 @@ import (
 	"github.com/rsteube/carapace/internal/env"
 	"github.com/rsteube/carapace/internal/shell/bash"
 	"github.com/rsteube/carapace/internal/shell/bash_ble"
+	"github.com/rsteube/carapace/internal/shell/clink"
+	cobrashell "github.com/rsteube/carapace/internal/shell/cobra"
 	"github.com/rsteube/carapace/internal/shell/elvish"
 	"github.com/rsteube/carapace/internal/shell/export"
 	"github.com/rsteube/carapace/internal/shell/fish"
 	"github.com/rsteube/carapace/internal/shell/ion"
+	"github.com/rsteube/carapace/internal/shell/murex"
 	"github.com/rsteube/carapace/internal/shell/nushell"
 	"github.com/rsteube/carapace/internal/shell/oil"
 	"github.com/rsteube/carapace/internal/shell/powershell"
 	"github.com/rsteube/carapace/internal/shell/spec"
 	"github.com/rsteube/carapace/internal/shell/tcsh"
 	"github.com/rsteube/carapace/internal/shell/xonsh"
+	"github.com/rsteube/carapace/internal/shell/yash"
 	"github.com/rsteube/carapace/internal/shell/zsh"
 	"github.com/rsteube/carapace/pkg/ps"
 	"github.com/rsteube/carapace/pkg/style"
@@ -33,16 +37,19 @@ func Snippet(cmd *cobra.Command, shell string) (string, error) {
 	shellSnippets := map[string]func(cmd *cobra.Command) string{
 		"bash":       bash.Snippet,
 		"bash-ble":   bash_ble.Snippet,
+		"clink":      clink.Snippet,
 		"export":     export.Snippet,
 		"fish":       fish.Snippet,
 		"elvish":     elvish.Snippet,
 		"ion":        ion.Snippet,
+		"murex":      murex.Snippet,
 		"nushell":    nushell.Snippet,
 		"oil":        oil.Snippet,
 		"powershell": powershell.Snippet,
 		"spec":       spec.Snippet,
 		"tcsh":       tcsh.Snippet,
 		"xonsh":      xonsh.Snippet,
+		"yash":       yash.Snippet,
 		"zsh":        zsh.Snippet,
 	}
 	if s, ok := shellSnippets[shell]; ok {
@@ -57,19 +64,26 @@ func Snippet(cmd *cobra.Command, shell string) (string, error) {
 	return "", fmt.Errorf("expected one of '%v' [was: %v]", strings.Join(expected, "', '"), shell)
 }
 
-func Value(shell string, value string, meta common.Meta, values common.RawValues) string { // TODO use context instead?
+func Value(shell string, value string, activeHelpConfig string, meta common.Meta, values common.RawValues) string { // TODO use context instead?
+	cobraRawValues := func(currentWord string, meta common.Meta, values common.RawValues) string {
+		return cobrashell.ActionRawValues(currentWord, activeHelpConfig, meta, values)
+	}
 	shellFuncs := map[string]func(currentWord string, meta common.Meta, values common.RawValues) string{
 		"bash":       bash.ActionRawValues,
 		"bash-ble":   bash_ble.ActionRawValues,
+		"clink":      clink.ActionRawValues,
+		"cobra":      cobraRawValues,
 		"fish":       fish.ActionRawValues,
 		"elvish":     elvish.ActionRawValues,
 		"export":     export.ActionRawValues,
 		"ion":        ion.ActionRawValues,
+		"murex":      murex.ActionRawValues,
 		"nushell":    nushell.ActionRawValues,
 		"oil":        oil.ActionRawValues,
 		"powershell": powershell.ActionRawValues,
 		"tcsh":       tcsh.ActionRawValues,
 		"xonsh":      xonsh.ActionRawValues,
+		"yash":       yash.ActionRawValues,
 		"zsh":        zsh.ActionRawValues,
 	}
 	if f, ok := shellFuncs[shell]; ok {
@@ -82,7 +96,7 @@ func Value(shell string, value string, meta common.Meta, values common.RawValues
 		}
 		filtered := values.FilterPrefix(value)
 		switch shell {
-		case "elvish", "export", "zsh": // shells with support for showing messages
+		case "cobra", "elvish", "export", "zsh": // shells with support for showing messages
 		default:
 			filtered = meta.Messages.Integrate(filtered, value)
 		}
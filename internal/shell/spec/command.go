@@ -7,12 +7,14 @@ type Command struct {
 	Group           string            `yaml:"group,omitempty"`
 	Flags           map[string]string `yaml:"flags,omitempty"`
 	PersistentFlags map[string]string `yaml:"persistentflags,omitempty"`
-	Completion      struct {
-		Flag          map[string][]string `yaml:"flag,omitempty"`
-		Positional    [][]string          `yaml:"positional,omitempty"`
-		PositionalAny []string            `yaml:"positionalany,omitempty"`
-		Dash          [][]string          `yaml:"dash,omitempty"`
-		DashAny       []string            `yaml:"dashany,omitempty"`
-	} `yaml:"completion,omitempty"`
-	Commands []Command `yaml:"commands,omitempty"`
+	Completion      Completion        `yaml:"completion,omitempty"`
+	Commands        []Command         `yaml:"commands,omitempty"`
+}
+
+type Completion struct {
+	Flag          map[string][]string `yaml:"flag,omitempty"`
+	Positional    [][]string          `yaml:"positional,omitempty"`
+	PositionalAny []string            `yaml:"positionalany,omitempty"`
+	Dash          [][]string          `yaml:"dash,omitempty"`
+	DashAny       []string            `yaml:"dashany,omitempty"`
 }
@@ -0,0 +1,130 @@
+package spec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/pflagfork"
+)
+
+// Flag is a parsed flag definition (e.g. `-s, --long=`).
+type Flag struct {
+	Longhand   string
+	Shorthand  string
+	Usage      string
+	Repeatable bool
+	Optarg     bool
+	Value      bool
+	Mode       pflagfork.Mode
+	NargsMin   int // minimum amount of arguments (0 if default)
+	NargsMax   int // maximum amount of arguments (0 if default, -1 if unlimited)
+}
+
+// ParseFlag parses a flag definition as created by pflagfork.Flag.Definition.
+//
+//	-s, --long*=
+//	--optarg?
+//	-s, -long
+//	--fixed=3
+//	--range=2..4
+//	--variadic=1..
+func ParseFlag(definition, usage string) (f Flag, err error) {
+	f.Usage = usage
+
+	s := definition
+	if matches := regexp.MustCompile(`=(\d+(\.\.\d*)?)$`).FindStringSubmatch(s); matches != nil {
+		if f.NargsMin, f.NargsMax, err = parseNargs(matches[1]); err != nil {
+			return f, fmt.Errorf("invalid nargs in flag definition: '%v'", definition)
+		}
+		s = strings.TrimSuffix(s, matches[1])
+	}
+
+	switch {
+	case strings.HasSuffix(s, "="):
+		f.Value = true
+		s = strings.TrimSuffix(s, "=")
+	case strings.HasSuffix(s, "?"):
+		f.Value = true
+		f.Optarg = true
+		s = strings.TrimSuffix(s, "?")
+	}
+
+	if strings.HasSuffix(s, "*") {
+		f.Repeatable = true
+		s = strings.TrimSuffix(s, "*")
+	}
+
+	invalid := fmt.Errorf("invalid flag definition: '%v'", definition)
+
+	splitted := strings.Split(s, ", ")
+	switch len(splitted) {
+	case 1:
+		switch {
+		case strings.HasPrefix(splitted[0], "--"):
+			f.Longhand = strings.TrimPrefix(splitted[0], "--")
+		case strings.HasPrefix(splitted[0], "-") && len(splitted[0]) == 2:
+			f.Shorthand = strings.TrimPrefix(splitted[0], "-")
+			f.Mode = pflagfork.ShorthandOnly
+		case strings.HasPrefix(splitted[0], "-"):
+			f.Longhand = strings.TrimPrefix(splitted[0], "-")
+			f.Mode = pflagfork.NameAsShorthand
+		default:
+			return f, invalid
+		}
+
+	case 2:
+		if !strings.HasPrefix(splitted[0], "-") || strings.HasPrefix(splitted[0], "--") {
+			return f, invalid
+		}
+		f.Shorthand = strings.TrimPrefix(splitted[0], "-")
+
+		switch {
+		case strings.HasPrefix(splitted[1], "--") && len(f.Shorthand) == 1:
+			f.Longhand = strings.TrimPrefix(splitted[1], "--")
+		case strings.HasPrefix(splitted[1], "--"):
+			return f, invalid // shorthand must be a single character in posix mode
+		case strings.HasPrefix(splitted[1], "-"):
+			f.Longhand = strings.TrimPrefix(splitted[1], "-")
+			f.Mode = pflagfork.NameAsShorthand
+		default:
+			return f, invalid
+		}
+
+	default:
+		return f, invalid
+	}
+
+	if (f.Longhand == "" && f.Shorthand == "") || strings.HasPrefix(f.Longhand, "-") {
+		return f, invalid
+	}
+	return f, nil
+}
+
+// parseNargs parses the amount of arguments consumed by a flag (maximum is `-1` if unlimited).
+//
+//	3
+//	2..4
+//	1..
+func parseNargs(nargs string) (min, max int, err error) {
+	matches := regexp.MustCompile(`^(\d+)(\.\.(\d*))?$`).FindStringSubmatch(nargs)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("invalid nargs: '%v'", nargs)
+	}
+
+	min, _ = strconv.Atoi(matches[1])
+	switch {
+	case matches[2] == "":
+		max = min
+	case matches[3] == "":
+		max = -1
+	default:
+		max, _ = strconv.Atoi(matches[3])
+	}
+
+	if min < 1 || (max >= 0 && max < min) {
+		return 0, 0, fmt.Errorf("invalid nargs: '%v'", nargs)
+	}
+	return min, max, nil
+}
@@ -0,0 +1,42 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/rsteube/carapace/internal/pflagfork"
+)
+
+func TestParseFlag(t *testing.T) {
+	tests := map[string]Flag{
+		"--bool":             {Longhand: "bool"},
+		"-b, --bool":         {Longhand: "bool", Shorthand: "b"},
+		"-c, --count*":       {Longhand: "count", Shorthand: "c", Repeatable: true},
+		"--string=":          {Longhand: "string", Value: true},
+		"-a, --array*=":      {Longhand: "array", Shorthand: "a", Repeatable: true, Value: true},
+		"--optarg?":          {Longhand: "optarg", Value: true, Optarg: true},
+		"-s":                 {Shorthand: "s", Mode: pflagfork.ShorthandOnly},
+		"-s, -nonposix=":     {Longhand: "nonposix", Shorthand: "s", Value: true, Mode: pflagfork.NameAsShorthand},
+		"-nonposix":          {Longhand: "nonposix", Mode: pflagfork.NameAsShorthand},
+		"-ab, -nonposix?":    {Longhand: "nonposix", Shorthand: "ab", Value: true, Optarg: true, Mode: pflagfork.NameAsShorthand},
+		"--fixed=3":          {Longhand: "fixed", Value: true, NargsMin: 3, NargsMax: 3},
+		"--range*=2..4":      {Longhand: "range", Value: true, Repeatable: true, NargsMin: 2, NargsMax: 4},
+		"-v, --variadic=1..": {Longhand: "variadic", Shorthand: "v", Value: true, NargsMin: 1, NargsMax: -1},
+		"--nargs3=":          {Longhand: "nargs3", Value: true},
+	}
+
+	for definition, expected := range tests {
+		actual, err := ParseFlag(definition, "")
+		if err != nil {
+			t.Errorf("%v: %v", definition, err.Error())
+		}
+		if actual != expected {
+			t.Errorf("%v: expected %#v was %#v", definition, expected, actual)
+		}
+	}
+
+	for _, definition := range []string{"", "bool", "-ab, --bool", "--a, --bool", "-a, -b, --c", "---invalid", "--zero=0", "--reverse=3..2"} {
+		if _, err := ParseFlag(definition, ""); err == nil {
+			t.Errorf("%v: should fail", definition)
+		}
+	}
+}
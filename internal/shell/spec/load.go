@@ -0,0 +1,9 @@
+package spec
+
+import "gopkg.in/yaml.v3"
+
+// Load parses a spec file.
+func Load(content []byte) (c Command, err error) {
+	err = yaml.Unmarshal(content, &c)
+	return
+}
@@ -8,6 +8,10 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// CompletionFor provides the completion section for given command.
+// It is set by carapace to access registered Actions (circumventing dependency issues).
+var CompletionFor func(cmd *cobra.Command) Completion
+
 // Snippet generates the spec file.
 func Snippet(cmd *cobra.Command) string {
 	m, _ := yaml.Marshal(command(cmd))
@@ -25,13 +29,16 @@ func command(cmd *cobra.Command) Command {
 		Commands:        make([]Command, 0),
 	}
 
+	if CompletionFor != nil {
+		c.Completion = CompletionFor(cmd)
+	}
+
 	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
-		if flag.Hidden {
+		if flag.Hidden || cmd.PersistentFlags().Lookup(flag.Name) != nil {
 			return
 		}
 		f := pflagfork.Flag{Flag: flag}
 		c.Flags[f.Definition()] = f.Usage
-
 	})
 
 	cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
@@ -40,7 +47,6 @@ func command(cmd *cobra.Command) Command {
 		}
 		f := pflagfork.Flag{Flag: flag}
 		c.PersistentFlags[f.Definition()] = f.Usage
-
 	})
 
 	for _, subcmd := range cmd.Commands() {
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/env"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -14,28 +16,13 @@ var sanitizer = strings.NewReplacer(
 	"\t", ``,
 )
 
-var quoter = strings.NewReplacer(
-	`&`, `\&`,
-	`<`, `\<`,
-	`>`, `\>`,
-	"`", "\\`",
-	`'`, `\'`,
-	`"`, `\"`,
-	`{`, ``, // TODO seems escaping is not working
-	`}`, ``, // TODO seems escaping is not working
-	`$`, `\$`,
-	`#`, `\#`,
-	`|`, `\|`,
-	`?`, `\?`,
-	`(`, `\(`,
-	`)`, `\)`,
-	`;`, `\;`,
-	` `, `\ `,
-	`[`, `\[`,
-	`]`, `\]`,
-	`*`, `\*`,
-	`\`, `\\`,
-)
+// quoteState returns the quote state of the current word (derived from the unparsed `COMMAND_LINE` provided by tcsh).
+func quoteState() quote.State {
+	if env.Quote() != "" {
+		return quote.Current()
+	}
+	return quote.Scan(os.Getenv("COMMAND_LINE"))
+}
 
 func commonPrefix(a, b string) string {
 	i := 0
@@ -97,13 +84,13 @@ func ActionRawValues(currentWord string, meta common.Meta, values common.RawValu
 	vals := make([]string, len(values))
 	for index, val := range values {
 		if len(values) == 1 {
-			vals[index] = quoter.Replace(sanitizer.Replace(val.Value))
+			vals[index] = quote.Tcsh.Quote(sanitizer.Replace(val.Value), quoteState(), meta.Nospace.Matches(val.Value))
 		} else {
 			if val.Description != "" {
 				// TODO seems actual value needs to be used or it won't be shown if the prefix doesn't match
-				vals[index] = fmt.Sprintf("%v_(%v)", quoter.Replace(sanitizer.Replace(val.Value)), quoter.Replace(strings.Replace(sanitizer.Replace(val.TrimmedDescription()), " ", "_", -1)))
+				vals[index] = fmt.Sprintf("%v_(%v)", quote.Tcsh.Quote(sanitizer.Replace(val.Value), quote.Escaped, false), quote.Tcsh.Quote(strings.Replace(sanitizer.Replace(val.TrimmedDescription()), " ", "_", -1), quote.Escaped, false))
 			} else {
-				vals[index] = quoter.Replace(sanitizer.Replace(val.Value))
+				vals[index] = quote.Tcsh.Quote(sanitizer.Replace(val.Value), quote.Escaped, false)
 			}
 		}
 	}
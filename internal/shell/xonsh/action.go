@@ -2,16 +2,15 @@ package xonsh
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer( // TODO
 	"\n", ``,
 	"\t", ``,
-	`'`, `\'`,
 )
 
 type richCompletion struct {
@@ -22,19 +21,12 @@ type richCompletion struct {
 
 // ActionRawValues formats values for xonsh.
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	state := quote.Current()
 	vals := make([]richCompletion, len(values))
 	for index, val := range values {
-		val.Value = sanitizer.Replace(val.Value)
-
-		if strings.ContainsAny(val.Value, ` ()[]{}*$?\"|<>&;#`+"`") {
-			if strings.Contains(val.Value, `\`) {
-				val.Value = fmt.Sprintf("r'%v'", val.Value) // backslash needs raw string
-			} else {
-				val.Value = fmt.Sprintf("'%v'", val.Value)
-			}
-		}
-
-		if !meta.Nospace.Matches(val.Value) {
+		nospace := meta.Nospace.Matches(val.Value)
+		val.Value = quote.Python.Quote(sanitizer.Replace(val.Value), state, nospace)
+		if !nospace {
 			val.Value = val.Value + " "
 		}
 
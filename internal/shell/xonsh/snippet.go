@@ -19,6 +19,7 @@ def _%v_completer(context):
     """carapace completer for %v"""
     if context.completing_command('%v'):
         from json import loads
+        from os import environ
         from subprocess import Popen, PIPE
         from xonsh.completers.tools import RichCompletion
         
@@ -26,7 +27,7 @@ def _%v_completer(context):
             """quick fix for partially quoted prefix completion ('prefix',<TAB>)"""
             return s.translate(str.maketrans('', '', '\'"'))
 
-        output, _ = Popen(['%v', '_carapace', 'xonsh', *[a.value for a in context.args], fix_prefix(context.prefix)], stdout=PIPE, stderr=PIPE).communicate()
+        output, _ = Popen(['%v', '_carapace', 'xonsh', *[a.value for a in context.args], fix_prefix(context.prefix)], stdout=PIPE, stderr=PIPE, env={**environ, 'CARAPACE_QUOTE': context.opening_quote[-1:]}).communicate()
         try:
             result = {RichCompletion(c["Value"], display=c["Display"], description=c["Description"], prefix_len=len(context.raw_prefix), append_closing_quote=False) for c in loads(output)}
         except:
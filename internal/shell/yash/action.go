@@ -0,0 +1,35 @@
+package yash
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/common"
+)
+
+var sanitizer = strings.NewReplacer(
+	"\n", ``,
+	"\r", ``,
+)
+
+func quote(s string) string {
+	return fmt.Sprintf("'%v'", strings.Replace(s, `'`, `'"'"'`, -1))
+}
+
+// ActionRawValues formats values for yash.
+func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
+	vals := make([]string, len(values))
+	for index, val := range values {
+		val.Value = sanitizer.Replace(val.Value)
+
+		args := []string{"complete"}
+		if description := sanitizer.Replace(val.TrimmedDescription()); description != "" {
+			args = append(args, "-D", quote(description))
+		}
+		if meta.Nospace.Matches(val.Value) {
+			args = append(args, "-T") // no trailing space
+		}
+		vals[index] = strings.Join(append(args, "--", quote(val.Value)), " ")
+	}
+	return strings.Join(vals, "\n")
+}
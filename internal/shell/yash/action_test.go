@@ -0,0 +1,63 @@
+package yash
+
+import (
+	"testing"
+
+	"github.com/rsteube/carapace/internal/common"
+)
+
+func TestActionRawValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   common.RawValues
+		messages []string
+		nospace  rune
+		expected string
+	}{
+		{
+			name:     "values and descriptions are single quoted",
+			values:   common.RawValues{{Value: "a b$c", Display: "a b$c", Description: "the $description"}},
+			expected: `complete -D 'the $description' -- 'a b$c'`,
+		},
+		{
+			name:     "single quotes are closed, double quoted and reopened",
+			values:   common.RawValues{{Value: "it's", Display: "it's", Description: "the 'first' value"}},
+			expected: `complete -D 'the '"'"'first'"'"' value' -- 'it'"'"'s'`,
+		},
+		{
+			name:     "newlines are removed",
+			values:   common.RawValues{{Value: "a\nb", Display: "a\nb"}},
+			expected: `complete -- 'ab'`,
+		},
+		{
+			name:     "empty description is omitted",
+			values:   common.RawValues{{Value: "plain", Display: "plain"}},
+			expected: `complete -- 'plain'`,
+		},
+		{
+			name:     "nospace",
+			values:   common.RawValues{{Value: "dir/", Display: "dir/"}, {Value: "file", Display: "file"}},
+			nospace:  '/',
+			expected: "complete -T -- 'dir/'\ncomplete -- 'file'",
+		},
+		{
+			name:     "messages",
+			messages: []string{"it's an error"},
+			expected: `complete -D 'it'"'"'s an error' -- 'ERR'` + "\n" + `complete -- '_'`,
+		},
+	}
+
+	for _, test := range tests {
+		meta := common.Meta{}
+		if test.nospace != 0 {
+			meta.Nospace.Add(test.nospace)
+		}
+		for _, message := range test.messages {
+			meta.Messages.Add(message)
+		}
+		values := meta.Messages.Integrate(test.values, "")
+		if s := ActionRawValues("", meta, values); s != test.expected {
+			t.Errorf("%v: expected %#v, got %#v", test.name, test.expected, s)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package yash provides yash completion
+package yash
+
+import (
+	"fmt"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/spf13/cobra"
+)
+
+// Snippet creates the yash completion script.
+func Snippet(cmd *cobra.Command) string {
+	return fmt.Sprintf(`function completion/%v {
+  eval "$(%v _carapace yash "${WORDS}" "${TARGETWORD}")"
+}
+`, cmd.Name(), uid.Executable())
+}
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
+	"github.com/rsteube/carapace/internal/quote"
 )
 
 var sanitizer = strings.NewReplacer(
@@ -13,51 +14,30 @@ var sanitizer = strings.NewReplacer(
 	"\t", ``,
 )
 
-// TODO verify these are correct/complete (copied from bash)
-var quoter = strings.NewReplacer(
-	`\`, `\\`,
-	`&`, `\&`,
-	`<`, `\<`,
-	`>`, `\>`,
-	"`", "\\`",
-	`'`, `\'`,
-	`"`, `\"`,
-	`{`, `\{`,
-	`}`, `\}`,
-	`$`, `\$`,
-	`#`, `\#`,
-	`|`, `\|`,
-	`?`, `\?`,
-	`(`, `\(`,
-	`)`, `\)`,
-	`;`, `\;`,
-	` `, `\ `,
-	`[`, `\[`,
-	`]`, `\]`,
-	`*`, `\*`,
-	`~`, `\~`,
-)
-
-func quoteValue(s string) string {
-	if strings.HasPrefix(s, "~/") || NamedDirectories.Matches(s) {
-		return "~" + quoter.Replace(strings.TrimPrefix(s, "~")) // assume file path expansion
+func quoteValue(s string, state quote.State, partial bool) string {
+	if unquoted := state == quote.Unquoted || state == quote.Escaped; unquoted && (strings.HasPrefix(s, "~/") || NamedDirectories.Matches(s)) {
+		return "~" + quote.Posix.Quote(strings.TrimPrefix(s, "~"), state, partial) // assume file path expansion
 	}
-	return quoter.Replace(s)
+	return quote.Posix.Quote(s, state, partial)
 }
 
 // ActionRawValues formats values for zsh
 func ActionRawValues(currentWord string, meta common.Meta, values common.RawValues) string {
-
+	state := quote.Current()
 	tagGroup := make([]string, 0)
 	values.EachTag(func(tag string, values common.RawValues) {
+		if tag == "" {
+			tag = "values" // used as group header
+		}
 		vals := make([]string, len(values))
 		displays := make([]string, len(values))
 		for index, val := range values {
+			nospace := meta.Nospace.Matches(val.Value)
 			val.Value = sanitizer.Replace(val.Value)
-			val.Value = quoteValue(val.Value)
+			val.Value = quoteValue(val.Value, state, nospace)
 			val.Value = strings.ReplaceAll(val.Value, `\`, `\\`) // TODO find out why `_describe` needs another backslash
 			val.Value = strings.ReplaceAll(val.Value, `:`, `\:`) // TODO find out why `_describe` needs another backslash
-			if !meta.Nospace.Matches(val.Value) {
+			if !nospace {
 				val.Value = val.Value + " "
 			}
 			val.Display = sanitizer.Replace(val.Display)
@@ -4,7 +4,7 @@ package zsh
 import (
 	"fmt"
 
-	"github.com/rsteube/carapace/internal/uid"
+	"github.com/rsteube/carapace/internal/server"
 	"github.com/spf13/cobra"
 )
 
@@ -16,11 +16,11 @@ function _%v_completion {
   
   # shellcheck disable=SC2086,SC2154,SC2155
   if echo ${words}"''" | xargs echo 2>/dev/null > /dev/null; then
-    local lines="$(echo ${words}"''" | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v _carapace zsh )"
+    local lines="$(echo ${words}"''" | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v zsh )"
   elif echo ${words} | sed "s/\$/'/" | xargs echo 2>/dev/null > /dev/null; then
-    local lines="$(echo ${words} | sed "s/\$/'/" | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v _carapace zsh)"
+    local lines="$(echo ${words} | sed "s/\$/'/" | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v zsh)"
   else
-    local lines="$(echo ${words} | sed 's/$/"/' | CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v _carapace zsh)"
+    local lines="$(echo ${words} | sed 's/$/"/' | CARAPACE_QUOTE="${compstate[quote]}" CARAPACE_ZSH_HASH_DIRS="$(hash -d)" xargs %v zsh)"
   fi
 
   local zstyle message data
@@ -28,6 +28,7 @@ function _%v_completion {
   # shellcheck disable=SC2154
   zstyle ":completion:${curcontext}:*" list-colors "${zstyle}"
   zstyle ":completion:${curcontext}:*" group-name ''
+  zstyle -T ":completion:${curcontext}:descriptions" format && zstyle ":completion:${curcontext}:descriptions" format $'%%B%%d%%b'
   [ -z "$message" ] || _message -r "${message}"
   
   local block tag displays values displaysArr valuesArr
@@ -42,5 +43,5 @@ function _%v_completion {
 }
 compquote '' 2>/dev/null && _%v_completion
 compdef _%v_completion %v
-`, cmd.Name(), cmd.Name(), uid.Executable(), uid.Executable(), uid.Executable(), cmd.Name(), cmd.Name(), cmd.Name())
+`, cmd.Name(), cmd.Name(), server.Client(), server.Client(), server.Client(), cmd.Name(), cmd.Name(), cmd.Name())
 }
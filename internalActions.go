@@ -1,6 +1,7 @@
 package carapace
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -74,6 +75,7 @@ func actionFlags(cmd *cobra.Command) Action {
 		isShorthandSeries := flagSet.IsShorthandSeries(c.Value)
 
 		vals := make([]string, 0)
+		required := make([]string, 0) // flags that are required and thus offered separately
 		flagSet.VisitAll(func(f *pflagfork.Flag) {
 			switch {
 			case f.Deprecated != "":
@@ -84,6 +86,11 @@ func actionFlags(cmd *cobra.Command) Action {
 				return // skip flag of group already set
 			}
 
+			target, s := &vals, f.Style()
+			if !f.Changed && (flagSet.IsRequired(f.Flag) || flagSet.IsRequiredTogether(f.Flag) || flagSet.IsOneRequired(f.Flag)) {
+				target, s = &required, strings.TrimSpace(style.Of(s, style.Carapace.FlagRequired))
+			}
+
 			if isShorthandSeries {
 				if f.Shorthand != "" && f.ShorthandDeprecated == "" {
 					for _, shorthand := range c.Value[1:] {
@@ -91,27 +98,40 @@ func actionFlags(cmd *cobra.Command) Action {
 							return // abort shorthand flag series if a previous one is not bool or count and requires an argument (no default value)
 						}
 					}
-					vals = append(vals, f.Shorthand, f.Usage, f.Style())
+					*target = append(*target, f.Shorthand, f.Usage, s)
 				}
 			} else {
 				switch f.Mode() {
 				case pflagfork.NameAsShorthand:
-					vals = append(vals, "-"+f.Name, f.Usage, f.Style())
+					*target = append(*target, "-"+f.Name, f.Usage, s)
 				case pflagfork.Default:
-					vals = append(vals, "--"+f.Name, f.Usage, f.Style())
+					*target = append(*target, "--"+f.Name, f.Usage, s)
+					if usage, ok := f.Negation(); ok {
+						*target = append(*target, "--no-"+f.Name, usage, s)
+					}
 				}
 
 				if f.Shorthand != "" && f.ShorthandDeprecated == "" {
-					vals = append(vals, "-"+f.Shorthand, f.Usage, f.Style())
+					*target = append(*target, "-"+f.Shorthand, f.Usage, s)
 				}
 			}
 		})
 
-		if isShorthandSeries {
-			return ActionStyledValuesDescribed(vals...).Prefix(c.Value).NoSpace('*')
+		action := func(vals []string, tag string) Action {
+			if isShorthandSeries {
+				return ActionStyledValuesDescribed(vals...).Prefix(c.Value).NoSpace('*').Tag(tag)
+			}
+			return ActionStyledValuesDescribed(vals...).MultiParts(".").Tag(tag) // multiparts completion for flags grouped with `.`
+		}
+		if len(required) == 0 {
+			return action(vals, "flags")
 		}
-		return ActionStyledValuesDescribed(vals...).MultiParts(".") // multiparts completion for flags grouped with `.`
-	}).Tag("flags")
+		// tagged like command groups so that required flags are offered first (tags are sorted)
+		return Batch(
+			action(required, "flags"),
+			action(vals, "other flags"),
+		).ToA()
+	})
 }
 
 func actionSubcommands(cmd *cobra.Command) Action {
@@ -129,3 +149,38 @@ func actionSubcommands(cmd *cobra.Command) Action {
 		return batch.ToA()
 	})
 }
+
+// actionFlagArgs completes the arguments of a flag consuming multiple ones by their index (the last Action is repeated).
+func actionFlagArgs(actions ...Action) Action {
+	return ActionCallback(func(c Context) Action {
+		switch {
+		case len(actions) == 0:
+			return ActionValues()
+		case len(c.Parts) < len(actions):
+			return actions[len(c.Parts)]
+		default:
+			return actions[len(actions)-1]
+		}
+	})
+}
+
+// actionMissingRequired adds flags that are required but not yet set to the usage.
+func actionMissingRequired(cmd *cobra.Command, a Action) Action {
+	return ActionCallback(func(c Context) Action {
+		invoked := a.Invoke(c)
+		flagSet := pflagfork.FlagSet{FlagSet: cmd.Flags()}
+		if missing := flagSet.MissingRequired(); len(missing) > 0 {
+			usage := invoked.meta.Usage
+			if usage == "" {
+				usage = cmd.UseLine()
+			}
+			invoked.meta.Usage = fmt.Sprintf("%v (missing required flags: %v)", usage, strings.Join(missing, ", "))
+		}
+		return invoked.ToA()
+	})
+}
+
+// actionNoMoreArgs indicates that the Args validator of given command does not accept further arguments.
+func actionNoMoreArgs(cmd *cobra.Command) Action {
+	return ActionMessage("no more arguments accepted").Usage(cmd.UseLine())
+}
@@ -0,0 +1,232 @@
+package carapace
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/uid"
+	"github.com/spf13/cobra"
+)
+
+// Check identifies a lint check.
+type Check string
+
+const (
+	CheckUnknownFlag    Check = "unknown-flag"    // FlagCompletion for a flag that does not exist
+	CheckPositionalArgs Check = "positional-args" // positional completion beyond what the Args validator accepts
+	CheckDashArgs       Check = "dash-args"       // DashCompletion on a command that does not accept dash arguments
+	CheckFlagAnnotation Check = "flag-annotation" // FlagCompletion conflicting with MarkFlagFilename/MarkFlagDirname
+	CheckHiddenCommand  Check = "hidden-command"  // completion for a hidden or deprecated command
+	CheckInvalidAction  Check = "invalid-action"  // static Action returning an error (e.g. invalid amount of arguments)
+)
+
+// Issue is a problem in the completion configuration.
+type Issue struct {
+	Command *cobra.Command
+	Flag    string // empty if not related to a flag
+	Check   Check
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Flag != "" {
+		return fmt.Sprintf("%v [%v] --%v: %v", uid.Command(i.Command), i.Check, i.Flag, i.Message)
+	}
+	return fmt.Sprintf("%v [%v]: %v", uid.Command(i.Command), i.Check, i.Message)
+}
+
+// Lint verifies the completion configuration of all commands.
+//
+//	for _, issue := range carapace.Lint() {
+//		fmt.Println(issue)
+//	}
+func Lint() []Issue {
+	issues := storage.check()
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].String() < issues[j].String() })
+	return issues
+}
+
+// argsProbe is the amount of additional arguments probed with the Args validator.
+const argsProbe = 10
+
+func lint(cmd *cobra.Command, e entry) []Issue {
+	issues := make([]Issue, 0)
+	add := func(check Check, flag string, format string, args ...interface{}) {
+		issues = append(issues, Issue{Command: cmd, Flag: flag, Check: check, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for name, action := range e.flag {
+		flag := cmd.LocalFlags().Lookup(name)
+		if flag == nil {
+			add(CheckUnknownFlag, name, "unknown flag")
+			continue
+		}
+
+		if _, ok := flag.Annotations[cobra.BashCompFilenameExt]; ok {
+			add(CheckFlagAnnotation, name, "FlagCompletion conflicts with MarkFlagFilename")
+		}
+		if _, ok := flag.Annotations[cobra.BashCompSubdirsInDir]; ok {
+			add(CheckFlagAnnotation, name, "FlagCompletion conflicts with MarkFlagDirname")
+		}
+
+		for _, msg := range lintAction(action) {
+			add(CheckInvalidAction, name, msg)
+		}
+	}
+
+	if accepted := acceptedArgs(cmd, len(e.positional)+argsProbe); accepted != nil {
+		accepts := func(min int) bool {
+			for n := min; n < len(accepted); n++ {
+				if accepted[n] {
+					return true
+				}
+			}
+			return false
+		}
+		maximum := 0
+		for n := range accepted {
+			if accepted[n] {
+				maximum = n
+			}
+		}
+
+		if count := len(e.positional); count > 0 && !accepts(count) {
+			add(CheckPositionalArgs, "", "PositionalCompletion for %v arguments but Args accepts at most %v", count, maximum)
+		}
+		if e.positionalAny != nil && !accepts(len(e.positional)+1) {
+			add(CheckPositionalArgs, "", "PositionalAnyCompletion but Args accepts at most %v arguments", maximum)
+		}
+		if (len(e.dash) > 0 || e.dashAny != nil) && !accepts(1) {
+			add(CheckDashArgs, "", "DashCompletion but Args accepts no arguments")
+		}
+	}
+
+	if (len(e.dash) > 0 || e.dashAny != nil) && cmd.DisableFlagParsing {
+		add(CheckDashArgs, "", "DashCompletion but `--` is not handled with DisableFlagParsing")
+	}
+
+	hasCompletion := len(e.flag) > 0 || len(e.positional) > 0 || e.positionalAny != nil || len(e.dash) > 0 || e.dashAny != nil
+	if hasCompletion && !isCarapaceCommand(cmd) {
+		switch {
+		case cmd.Hidden:
+			add(CheckHiddenCommand, "", "completion for hidden command")
+		case cmd.Deprecated != "":
+			add(CheckHiddenCommand, "", "completion for deprecated command")
+		}
+	}
+
+	for index, action := range e.positional {
+		for _, msg := range lintAction(action) {
+			add(CheckInvalidAction, "", "PositionalCompletion[%v]: %v", index, msg)
+		}
+	}
+	for index, action := range e.dash {
+		for _, msg := range lintAction(action) {
+			add(CheckInvalidAction, "", "DashCompletion[%v]: %v", index, msg)
+		}
+	}
+	for _, msg := range lintAction(orEmpty(e.positionalAny)) {
+		add(CheckInvalidAction, "", "PositionalAnyCompletion: %v", msg)
+	}
+	for _, msg := range lintAction(orEmpty(e.dashAny)) {
+		add(CheckInvalidAction, "", "DashAnyCompletion: %v", msg)
+	}
+	return issues
+}
+
+// lintAction invokes static Actions and returns their messages.
+func lintAction(a Action) []string {
+	if !a.static {
+		return nil
+	}
+	return a.Invoke(Context{}).meta.Messages.Get()
+}
+
+// probedValidators contains the cobra validators that are safe to probe as they only depend on the arguments.
+// Custom validators are skipped as they might have side effects or be expensive.
+var probedValidators = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, validator := range []cobra.PositionalArgs{
+		cobra.NoArgs,
+		cobra.ArbitraryArgs,
+		cobra.OnlyValidArgs,
+		cobra.MinimumNArgs(0),
+		cobra.MaximumNArgs(0),
+		cobra.ExactArgs(0),
+		cobra.RangeArgs(0, 0),
+	} {
+		m[validatorName(validator)] = true
+	}
+	return m
+}()
+
+// validatorName returns the function name of given validator (closures are named after their enclosing function).
+func validatorName(validator cobra.PositionalArgs) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(validator).Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// acceptedArgs probes the Args validator of given command with up to max arguments.
+// Returns nil if no known cobra validator is set or it does not accept any of them (e.g. depends on argument content).
+func acceptedArgs(cmd *cobra.Command, max int) (accepted []bool) {
+	if cmd.Args == nil || !probedValidators[validatorName(cmd.Args)] {
+		return nil
+	}
+
+	arg := "_"
+	if len(cmd.ValidArgs) > 0 {
+		arg = strings.SplitN(cmd.ValidArgs[0], "\t", 2)[0]
+	}
+
+	known := false
+	accepted = make([]bool, max+1)
+	for n := range accepted {
+		args := make([]string, n)
+		for i := range args {
+			args[i] = arg
+		}
+		accepted[n] = func() (ok bool) {
+			defer func() {
+				if r := recover(); r != nil {
+					ok = false
+				}
+			}()
+			return cmd.Args(cmd, args) == nil
+		}()
+		known = known || accepted[n]
+	}
+
+	if !known {
+		return nil
+	}
+	return
+}
+
+// acceptsMoreArgs returns false if the Args validator of given command is known to reject more than given amount of arguments.
+func acceptsMoreArgs(cmd *cobra.Command, count int) bool {
+	accepted := acceptedArgs(cmd, count+1+argsProbe)
+	if accepted == nil {
+		return true // unknown
+	}
+	for n := count + 1; n < len(accepted); n++ {
+		if accepted[n] {
+			return true
+		}
+	}
+	return false
+}
+
+// isCarapaceCommand returns true if given command is the hidden completion command (or one of its subcommands).
+func isCarapaceCommand(cmd *cobra.Command) bool {
+	for current := cmd; current != nil; current = current.Parent() {
+		if current.Name() == "_carapace" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package carapace
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func lintChecks(cmd *cobra.Command) []Check {
+	checks := make([]Check, 0)
+	for _, issue := range lint(cmd, storage.get(cmd)) {
+		checks = append(checks, issue.Check)
+	}
+	return checks
+}
+
+func assertChecks(t *testing.T, cmd *cobra.Command, expected ...Check) {
+	t.Helper()
+	if expected == nil {
+		expected = []Check{}
+	}
+	if actual := lintChecks(cmd); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestLintPositionalArgs(t *testing.T) {
+	cmd := &cobra.Command{Use: "lint", Args: cobra.ExactArgs(1)}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	assertChecks(t, cmd)
+
+	Gen(cmd).PositionalCompletion(ActionValues("1"), ActionValues("2"))
+	assertChecks(t, cmd, CheckPositionalArgs)
+
+	cmd = &cobra.Command{Use: "lint", Args: cobra.RangeArgs(2, 3)}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	Gen(cmd).PositionalAnyCompletion(ActionValues("any"))
+	assertChecks(t, cmd)
+
+	cmd = &cobra.Command{Use: "lint", Args: cobra.MaximumNArgs(1)}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	Gen(cmd).PositionalAnyCompletion(ActionValues("any"))
+	assertChecks(t, cmd, CheckPositionalArgs)
+
+	cmd = &cobra.Command{Use: "lint", Args: cobra.MatchAll(cobra.NoArgs, cobra.OnlyValidArgs)}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	assertChecks(t, cmd) // combined validators are unknown
+
+	cmd = &cobra.Command{Use: "lint", Args: func(cmd *cobra.Command, args []string) error {
+		t.Error("custom validator should not be probed")
+		return nil
+	}}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	assertChecks(t, cmd)
+}
+
+func TestLintDashArgs(t *testing.T) {
+	cmd := &cobra.Command{Use: "lint", Args: cobra.NoArgs}
+	Gen(cmd).DashAnyCompletion(ActionValues("dash"))
+	assertChecks(t, cmd, CheckDashArgs)
+
+	cmd = &cobra.Command{Use: "lint", DisableFlagParsing: true}
+	Gen(cmd).DashCompletion(ActionValues("dash"))
+	assertChecks(t, cmd, CheckDashArgs)
+
+	cmd = &cobra.Command{Use: "lint", Args: cobra.ArbitraryArgs}
+	Gen(cmd).DashCompletion(ActionValues("dash"))
+	assertChecks(t, cmd)
+}
+
+func TestLintFlag(t *testing.T) {
+	cmd := &cobra.Command{Use: "lint"}
+	cmd.Flags().String("file", "", "")
+	cmd.Flags().String("dir", "", "")
+	_ = cmd.MarkFlagFilename("file", "txt")
+	_ = cmd.MarkFlagDirname("dir")
+
+	Gen(cmd).FlagCompletion(ActionMap{
+		"file":    ActionFiles(),
+		"dir":     ActionDirectories(),
+		"unknown": ActionValues(),
+	})
+
+	issues := make(map[string]Check)
+	for _, issue := range lint(cmd, storage.get(cmd)) {
+		issues[issue.Flag] = issue.Check
+	}
+	expected := map[string]Check{
+		"file":    CheckFlagAnnotation,
+		"dir":     CheckFlagAnnotation,
+		"unknown": CheckUnknownFlag,
+	}
+	if !reflect.DeepEqual(expected, issues) {
+		t.Errorf("expected %v, got %v", expected, issues)
+	}
+}
+
+func TestLintHiddenCommand(t *testing.T) {
+	cmd := &cobra.Command{Use: "lint", Hidden: true}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	assertChecks(t, cmd, CheckHiddenCommand)
+
+	cmd = &cobra.Command{Use: "lint", Deprecated: "use something else"}
+	Gen(cmd).PositionalCompletion(ActionValues("1"))
+	assertChecks(t, cmd, CheckHiddenCommand)
+
+	carapaceCmd, _, _ := cmd.Find([]string{"_carapace"})
+	assertChecks(t, carapaceCmd)
+}
+
+func TestLintInvalidAction(t *testing.T) {
+	cmd := &cobra.Command{Use: "lint"}
+	cmd.Flags().String("flag", "", "")
+
+	Gen(cmd).FlagCompletion(ActionMap{
+		"flag": ActionValuesDescribed("a", "one", "b"),
+	})
+	Gen(cmd).PositionalCompletion(
+		ActionStyledValues("a", "blue", "b"),
+		ActionCallback(func(c Context) Action {
+			t.Error("non-static action should not be invoked")
+			return ActionValues()
+		}),
+		ActionStyledValues("a", "blue", "b").Cache(time.Minute), // wrapped callback is no longer static
+	)
+	Gen(cmd).DashAnyCompletion(ActionStyledValuesDescribed("a", "one"))
+
+	issues := lint(cmd, storage.get(cmd))
+	if len(issues) != 3 {
+		t.Fatalf("expected 3 issues: %v", issues)
+	}
+	for _, issue := range issues {
+		if issue.Check != CheckInvalidAction {
+			t.Errorf("unexpected issue: %v", issue)
+		}
+	}
+}
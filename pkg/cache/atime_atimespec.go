@@ -0,0 +1,17 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package cache
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func accessTime(info os.FileInfo) time.Time {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(int64(stat.Atimespec.Sec), int64(stat.Atimespec.Nsec))
+	}
+	return info.ModTime()
+}
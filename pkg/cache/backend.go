@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"os"
+	"time"
+)
+
+// Entry describes a cached value.
+type Entry struct {
+	Key        string
+	ModTime    time.Time
+	AccessTime time.Time // last Load (or ModTime if never loaded)
+	Size       int64
+}
+
+// Cache persists the values of cached Actions.
+// Keys are slash separated paths like `{{callerChecksum}}/{{cacheChecksum}}`.
+type Cache interface {
+	// Load returns the content and modification time stored for key and updates its access time.
+	// A missing key returns an error for which os.IsNotExist is true.
+	Load(key string) (content []byte, modTime time.Time, err error)
+	// Peek returns the content stored for key without updating its access time.
+	// A missing key returns an error for which os.IsNotExist is true.
+	Peek(key string) (content []byte, err error)
+	// Write stores content for key.
+	Write(key string, content []byte) error
+	// Delete removes key.
+	Delete(key string) error
+	// List returns all entries sorted by key.
+	List() ([]Entry, error)
+}
+
+func errNotExist(op, key string) error {
+	return &os.PathError{Op: op, Path: key, Err: os.ErrNotExist}
+}
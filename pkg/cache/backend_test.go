@@ -0,0 +1,134 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testBackend(t *testing.T, c Cache) {
+	if _, _, err := c.Load("caller/missing"); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error: %v", err)
+	}
+
+	if err := c.Write("caller/b", []byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Write("caller/a", []byte("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	if content, err := c.Peek("caller/b"); err != nil || string(content) != "second" {
+		t.Errorf("unexpected peek: %#v %v", string(content), err)
+	}
+
+	content, modTime, err := c.Load("caller/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "first" || modTime.IsZero() {
+		t.Errorf("unexpected entry: %#v %v", string(content), modTime)
+	}
+
+	entries, err := c.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make([]string, 0)
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	if expected := []string{"caller/a", "caller/b"}; !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+	if entries[1].Size != 6 {
+		t.Errorf("expected size 6, got %v", entries[1].Size)
+	}
+	if entries[0].AccessTime.Before(entries[0].ModTime) {
+		t.Errorf("expected access time not to be before modification time: %v", entries[0].AccessTime)
+	}
+
+	if err := c.Delete("caller/a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.Load("caller/a"); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error: %v", err)
+	}
+}
+
+func testAccessTime(t *testing.T, c Cache) {
+	if err := c.Write("caller/accessed", nil); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, _, err := c.Load("caller/accessed"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := c.List()
+	if len(entries) != 1 || !entries[0].AccessTime.After(entries[0].ModTime) {
+		t.Errorf("expected access time to be updated: %#v", entries)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if _, err := c.Peek("caller/accessed"); err != nil {
+		t.Fatal(err)
+	}
+	if peeked, _ := c.List(); len(peeked) != 1 || !peeked[0].AccessTime.Equal(entries[0].AccessTime) {
+		t.Errorf("expected access time not to be updated by peek: %#v", peeked)
+	}
+}
+
+func TestDirectory(t *testing.T) {
+	testBackend(t, Directory(t.TempDir()))
+	testAccessTime(t, Directory(t.TempDir()))
+
+	if entries, err := Directory(filepath.Join(t.TempDir(), "missing")).List(); err != nil || len(entries) != 0 {
+		t.Errorf("expected empty list for missing directory: %v %v", entries, err)
+	}
+	if err := Directory(t.TempDir()).Write("../escape", nil); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testBackend(t, Memory())
+	testAccessTime(t, Memory())
+}
+
+func TestSingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store")
+	testBackend(t, SingleFile(path))
+
+	if entries, err := SingleFile(path).List(); err != nil || len(entries) != 1 {
+		t.Errorf("expected entries to be persisted: %v %v", entries, err)
+	}
+}
+
+func TestSingleFileConcurrent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file locking not supported")
+	}
+
+	path := filepath.Join(t.TempDir(), "store")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := SingleFile(path).Write(fmt.Sprintf("caller/%v", i), nil); err != nil { // separate instances only share the lock file
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if entries, err := SingleFile(path).List(); err != nil || len(entries) != 20 {
+		t.Errorf("expected no lost writes: %v %v", len(entries), err)
+	}
+}
@@ -1,4 +1,4 @@
-// Package cache provides cache keys
+// Package cache provides cache keys and backends
 package cache
 
 import (
@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type directory string
+
+// Directory creates a Cache storing each entry as a separate file in given directory.
+func Directory(dir string) Cache {
+	return directory(dir)
+}
+
+func (d directory) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid cache key: '%v'", key)
+	}
+	return filepath.Join(string(d), filepath.FromSlash(key)), nil
+}
+
+func (d directory) Load(key string) (content []byte, modTime time.Time, err error) {
+	var path string
+	if path, err = d.path(key); err != nil {
+		return
+	}
+
+	var stat os.FileInfo
+	if stat, err = os.Stat(path); err == nil {
+		modTime = stat.ModTime()
+		if content, err = os.ReadFile(path); err == nil {
+			_ = os.Chtimes(path, time.Now(), modTime) // explicitly track access as filesystems might be mounted with `noatime`
+		}
+	}
+	return
+}
+
+func (d directory) Peek(key string) ([]byte, error) {
+	path, err := d.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func (d directory) Write(key string, content []byte) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path) // atomic replace so concurrent readers never see partial content
+}
+
+func (d directory) Delete(key string) error {
+	path, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != filepath.Clean(string(d)) {
+		_ = os.Remove(dir) // remove caller directory if empty
+	}
+	return nil
+}
+
+func (d directory) List() ([]Entry, error) {
+	entries := make([]Entry, 0)
+	err := filepath.Walk(string(d), func(path string, info os.FileInfo, err error) error {
+		switch {
+		case os.IsNotExist(err) && path == string(d):
+			return filepath.SkipDir
+		case err != nil:
+			return err
+		case info.IsDir(), strings.HasSuffix(path, ".tmp"): // skip pending writes
+			return nil
+		}
+
+		rel, err := filepath.Rel(string(d), path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Entry{
+			Key:        filepath.ToSlash(rel),
+			ModTime:    info.ModTime(),
+			AccessTime: accessTime(info),
+			Size:       info.Size(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package cache
+
+import "os"
+
+func lockFile(f *os.File) error {
+	return nil // file locking not supported (only serialized within the process)
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
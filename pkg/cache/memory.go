@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	content    []byte
+	modTime    time.Time
+	accessTime time.Time
+}
+
+type memory struct {
+	mutex   sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+// Memory creates a Cache storing entries in memory.
+// Useful for long running processes and tests.
+func Memory() Cache {
+	return &memory{entries: make(map[string]memoryEntry)}
+}
+
+func (m *memory) Load(key string) ([]byte, time.Time, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, time.Time{}, errNotExist("load", key)
+	}
+	entry.accessTime = time.Now()
+	m.entries[key] = entry
+	return append([]byte{}, entry.content...), entry.modTime, nil
+}
+
+func (m *memory) Peek(key string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, errNotExist("peek", key)
+	}
+	return append([]byte{}, entry.content...), nil
+}
+
+func (m *memory) Write(key string, content []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	m.entries[key] = memoryEntry{
+		content:    append([]byte{}, content...),
+		modTime:    now,
+		accessTime: now,
+	}
+	return nil
+}
+
+func (m *memory) Delete(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, ok := m.entries[key]; !ok {
+		return errNotExist("delete", key)
+	}
+	delete(m.entries, key)
+	return nil
+}
+
+func (m *memory) List() ([]Entry, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(m.entries))
+	for key, entry := range m.entries {
+		entries = append(entries, Entry{
+			Key:        key,
+			ModTime:    entry.modTime,
+			AccessTime: entry.accessTime,
+			Size:       int64(len(entry.content)),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
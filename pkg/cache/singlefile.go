@@ -0,0 +1,201 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type singleFileEntry struct {
+	Content    []byte
+	ModTime    time.Time
+	AccessTime time.Time
+}
+
+// accessResolution limits how often access times are persisted as this rewrites the whole store.
+const accessResolution = time.Minute
+
+type singleFile struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// SingleFile creates a Cache storing all entries in a single file.
+// It avoids creating thousands of small files at the cost of reading the whole store on access.
+// Modifications are serialized between processes with a lock file (`{{path}}.lock`) on platforms supporting flock.
+// Access times are only tracked with a resolution of one minute.
+func SingleFile(path string) Cache {
+	return &singleFile{path: path}
+}
+
+// lock serializes the read-modify-write cycles of all processes sharing the store.
+func (s *singleFile) lock() (unlock func(), err error) {
+	s.mutex.Lock()
+	defer func() {
+		if err != nil {
+			s.mutex.Unlock()
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return func() {
+		_ = unlockFile(f)
+		f.Close()
+		s.mutex.Unlock()
+	}, nil
+}
+
+func (s *singleFile) read() (map[string]singleFileEntry, error) {
+	entries := make(map[string]singleFileEntry)
+	content, err := os.ReadFile(s.path)
+	switch {
+	case os.IsNotExist(err):
+		return entries, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (s *singleFile) write(entries map[string]singleFileEntry) error {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(entries); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buffer.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path) // atomic replace
+}
+
+func (s *singleFile) Load(key string) ([]byte, time.Time, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	entry, ok := entries[key]
+	if !ok {
+		return nil, time.Time{}, errNotExist("load", key)
+	}
+
+	if now := time.Now(); now.Sub(entry.AccessTime) > accessResolution {
+		entry.AccessTime = now
+		entries[key] = entry
+		_ = s.write(entries)
+	}
+	return entry.Content, entry.ModTime, nil
+}
+
+func (s *singleFile) Peek(key string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := s.read() // no lock file needed as the store is replaced atomically
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := entries[key]
+	if !ok {
+		return nil, errNotExist("peek", key)
+	}
+	return entry.Content, nil
+}
+
+func (s *singleFile) Write(key string, content []byte) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		entries = make(map[string]singleFileEntry) // replace corrupt store
+	}
+	now := time.Now()
+	entries[key] = singleFileEntry{Content: content, ModTime: now, AccessTime: now}
+	return s.write(entries)
+}
+
+func (s *singleFile) Delete(key string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[key]; !ok {
+		return errNotExist("delete", key)
+	}
+	delete(entries, key)
+	return s.write(entries)
+}
+
+func (s *singleFile) List() ([]Entry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entries, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Entry, 0, len(entries))
+	for key, entry := range entries {
+		result = append(result, Entry{
+			Key:        key,
+			ModTime:    entry.ModTime,
+			AccessTime: entry.AccessTime,
+			Size:       int64(len(entry.Content)),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	return result, nil
+}
@@ -26,12 +26,16 @@ func DetermineShell() string {
 				return "bash-ble"
 			}
 			return "bash"
+		case "cmd":
+			return "clink"
 		case "elvish":
 			return "elvish"
 		case "fish":
 			return "fish"
 		case "ion":
 			return "ion"
+		case "murex":
+			return "murex"
 		case "nu":
 			return "nushell"
 		case "oil":
@@ -46,6 +50,8 @@ func DetermineShell() string {
 			return "tcsh"
 		case "xonsh":
 			return "xonsh"
+		case "yash":
+			return "yash"
 		case "zsh":
 			return "zsh"
 		default:
@@ -164,7 +164,7 @@ func (r run) invoke(a carapace.Action) string {
 // Expects validates output of Run with given Action.
 func (r run) Expect(expected carapace.Action) {
 	r.t.Run(r.id, func(t *testing.T) {
-		// t.Parallel() TODO prevent concurrent map write for this (storage.go)
+		// t.Parallel() TODO sandbox directory is removed before parallel subtests finish
 		assert.Equal(r.t, r.invoke(expected), r.invoke(r.actual))
 	})
 }
@@ -62,6 +62,7 @@ type carapace struct {
 	FlagMultiArg string `desc:"flag with multiple arguments" tag:"flag styles"`
 	FlagNoArg    string `desc:"flag without argument" tag:"flag styles"`
 	FlagOptArg   string `desc:"flag with optional argument" tag:"flag styles"`
+	FlagRequired string `desc:"required flag" tag:"flag styles"`
 }
 
 var Carapace = carapace{
@@ -102,6 +103,7 @@ var Carapace = carapace{
 	FlagMultiArg: Magenta,
 	FlagNoArg:    Default,
 	FlagOptArg:   Yellow,
+	FlagRequired: Underlined,
 }
 
 // Highlight returns the style for given level (0..n)
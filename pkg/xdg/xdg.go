@@ -1,6 +1,9 @@
 package xdg
 
-import "os"
+import (
+	"fmt"
+	"os"
+)
 
 // UserCacheDir returns the cache base directory.
 func UserCacheDir() (dir string, err error) {
@@ -17,3 +20,11 @@ func UserConfigDir() (dir string, err error) {
 	}
 	return
 }
+
+// UserRuntimeDir returns the runtime base directory (falls back to a user specific folder in os.TempDir).
+func UserRuntimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	return fmt.Sprintf("%v/carapace-%v", os.TempDir(), os.Getuid())
+}
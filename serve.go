@@ -0,0 +1,144 @@
+package carapace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rsteube/carapace/internal/pflagfork"
+	"github.com/rsteube/carapace/internal/server"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// requestTimeout limits the time a client may take to send its request.
+const requestTimeout = 5 * time.Second
+
+// serve starts a completion server on given unix socket.
+// Requests are handled sequentially as they alter the process environment, working directory and flag state.
+func serve(carapaceCmd *cobra.Command, socket string) error {
+	if err := server.PrepareDir(filepath.Dir(socket)); err != nil {
+		return err
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) { // stale socket from a previous run
+		return err
+	}
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	LOG.Printf("serving completion on %#v", socket)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		listener.Close() // also removes the socket
+	}()
+
+	return serveListener(carapaceCmd, listener)
+}
+
+func serveListener(carapaceCmd *cobra.Command, listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		handleRequest(carapaceCmd, conn)
+	}
+}
+
+func handleRequest(carapaceCmd *cobra.Command, conn net.Conn) {
+	defer conn.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			LOG.Printf("recovered from panic while handling request: %v", r)
+		}
+	}()
+
+	if err := server.Authorize(conn); err != nil {
+		LOG.Printf("rejected request: %v", err.Error())
+		return
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(requestTimeout)); err != nil {
+		LOG.Printf("failed to set read deadline: %v", err.Error())
+		return
+	}
+	content, err := io.ReadAll(conn)
+	if err != nil {
+		LOG.Printf("failed to read request: %v", err.Error())
+		return
+	}
+
+	request, err := server.ParseRequest(content)
+	if err != nil {
+		LOG.Print(err.Error())
+		return
+	}
+	LOG.Printf("handling request %#v", request.Args)
+
+	restore, err := applyRequestState(request)
+	if err != nil {
+		LOG.Print(err.Error())
+		return
+	}
+	defer restore()
+
+	resetFlags(carapaceCmd.Root())
+	runCallback(carapaceCmd, request.Args, conn, io.Discard)
+}
+
+// applyRequestState changes working directory and environment to the ones of given request.
+// The returned function restores the previous state.
+func applyRequestState(request server.Request) (restore func(), err error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine working directory: %v", err.Error())
+	}
+	environ := os.Environ()
+
+	if err := os.Chdir(request.Dir); err != nil {
+		return nil, fmt.Errorf("failed to change directory: %v", err.Error())
+	}
+	setEnviron(request.Env)
+
+	return func() {
+		setEnviron(environ)
+		if err := os.Chdir(wd); err != nil {
+			LOG.Printf("failed to restore directory: %v", err.Error())
+		}
+	}, nil
+}
+
+func setEnviron(environ []string) {
+	os.Clearenv()
+	for _, e := range environ {
+		if splitted := strings.SplitN(e, "=", 2); len(splitted) == 2 {
+			os.Setenv(splitted[0], splitted[1])
+		}
+	}
+}
+
+// resetFlags restores the flag state of given command and its subcommands.
+func resetFlags(cmd *cobra.Command) {
+	for _, flagSet := range []*pflag.FlagSet{cmd.Flags(), cmd.PersistentFlags()} {
+		(pflagfork.FlagSet{FlagSet: flagSet}).Reset()
+		flagSet.Init(cmd.Name(), pflag.ContinueOnError) // resets ArgsLenAtDash (cobra uses ContinueOnError)
+	}
+	for _, subcmd := range cmd.Commands() {
+		resetFlags(subcmd)
+	}
+}
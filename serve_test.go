@@ -0,0 +1,95 @@
+package carapace
+
+import (
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rsteube/carapace/internal/server"
+	"github.com/spf13/cobra"
+)
+
+func TestServe(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "serve",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.Flags().String("flag", "", "")
+	cmd.Flags().StringSlice("slice", []string{"default"}, "")
+	cmd.Flags().StringToString("map", map[string]string{"k": "default"}, "")
+
+	Gen(cmd).PositionalAnyCompletion(
+		ActionCallback(func(c Context) Action {
+			m, _ := c.flags().GetStringToString("map")
+			return ActionValues(c.GetString("flag"), strings.Join(c.GetStringSlice("slice"), "+"), "map-"+m["k"], c.Getenv("SERVE_TEST"))
+		}),
+	)
+
+	environ := os.Environ()
+	wd, _ := os.Getwd()
+	defer func() {
+		os.Clearenv()
+		for _, e := range environ {
+			if splitted := strings.SplitN(e, "=", 2); len(splitted) == 2 {
+				os.Setenv(splitted[0], splitted[1])
+			}
+		}
+		os.Chdir(wd)
+	}()
+
+	socket := t.TempDir() + "/serve.sock"
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer listener.Close()
+
+	carapaceCmd, _, _ := cmd.Find([]string{"_carapace"})
+	go serveListener(carapaceCmd, listener)
+
+	request := func(args ...string) string {
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		defer conn.Close()
+
+		r := server.Request{
+			Dir:  t.TempDir(),
+			Env:  append(os.Environ(), "SERVE_TEST=env"),
+			Args: append([]string{"export", "_"}, args...),
+		}
+		if _, err := conn.Write(r.Bytes()); err != nil {
+			t.Fatal(err.Error())
+		}
+		conn.(*net.UnixConn).CloseWrite()
+
+		output, _ := io.ReadAll(conn)
+		return string(output)
+	}
+
+	if output := request("--flag", "first", "--slice", "a", ""); !strings.Contains(output, `"value":"first"`) || !strings.Contains(output, `"value":"a"`) || !strings.Contains(output, `"value":"env"`) {
+		t.Error(output)
+	}
+
+	if output := request(""); strings.Contains(output, `"value":"first"`) || !strings.Contains(output, `"value":"default"`) {
+		t.Errorf("flag state should be reset: %v", output)
+	}
+
+	if output := request("--slice", "b", "--map", "k=changed", ""); !strings.Contains(output, `"value":"b"`) || strings.Contains(output, "default") || !strings.Contains(output, `"value":"map-changed"`) {
+		t.Errorf("slice should replace default after reset: %v", output)
+	}
+
+	if output := request(""); !strings.Contains(output, `"value":"map-default"`) {
+		t.Errorf("map should be reset: %v", output)
+	}
+
+	if _, ok := os.LookupEnv("SERVE_TEST"); ok {
+		t.Error("environment should be restored")
+	}
+	if current, _ := os.Getwd(); current != wd {
+		t.Errorf("working directory should be restored: %v", current)
+	}
+}
@@ -0,0 +1,295 @@
+package carapace
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rsteube/carapace/internal/pflagfork"
+	"github.com/rsteube/carapace/internal/shell/spec"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+func init() {
+	spec.CompletionFor = specCompletion
+}
+
+// macro creates a spec macro like `$files([.md, go.mod])`.
+func macro(name string, args ...string) string {
+	if len(args) == 0 {
+		return "$" + name
+	}
+	return fmt.Sprintf("$%v([%v])", name, strings.Join(args, ", "))
+}
+
+// parseMacro splits a macro like `$files([.md, go.mod])` into name and arguments.
+func parseMacro(s string) (name string, args []string, err error) {
+	r := regexp.MustCompile(`^\$(?P<name>[a-zA-Z0-9_.]+)(\(\[(?P<args>.*)\]\))?$`)
+	matches := r.FindStringSubmatch(s)
+	if matches == nil {
+		return "", nil, fmt.Errorf("malformed macro: '%v'", s)
+	}
+
+	if matches[3] != "" {
+		args = strings.Split(matches[3], ", ")
+	}
+	return matches[1], args, nil
+}
+
+// specValues joins grouped values (value, description, style) with tabs as used in spec files.
+func specValues(values []string, described, styled bool) []string {
+	size := 1
+	if described {
+		size++
+	}
+	if styled {
+		size++
+	}
+
+	if len(values)%size != 0 {
+		return nil // invalid amount of arguments
+	}
+
+	result := make([]string, 0, len(values)/size)
+	for i := 0; i < len(values); i += size {
+		fields := []string{values[i], "", ""}
+		if described {
+			fields[1] = values[i+1]
+		}
+		if styled {
+			fields[2] = values[i+size-1]
+		}
+		result = append(result, strings.TrimRight(strings.Join(fields, "\t"), "\t"))
+	}
+	return result
+}
+
+// specStyled sets the style of values (macros are kept as is).
+func specStyled(values []string, s string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !strings.HasPrefix(value, "$") {
+			fields := append(strings.SplitN(value, "\t", 3), "", "")[:3]
+			fields[2] = s
+			value = strings.TrimRight(strings.Join(fields, "\t"), "\t")
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+func specActions(actions []Action) [][]string {
+	result := make([][]string, 0, len(actions))
+	known := false
+	for _, action := range actions {
+		if action.spec != nil {
+			known = true
+			result = append(result, action.spec)
+		} else {
+			result = append(result, []string{})
+		}
+	}
+
+	if !known {
+		return nil
+	}
+	return result
+}
+
+func specCompletion(cmd *cobra.Command) spec.Completion {
+	entry := storage.get(cmd)
+
+	completion := spec.Completion{
+		Positional:    specActions(entry.positional),
+		PositionalAny: orEmpty(entry.positionalAny).spec,
+		Dash:          specActions(entry.dash),
+		DashAny:       orEmpty(entry.dashAny).spec,
+	}
+
+	for name, action := range entry.flag {
+		if action.spec == nil {
+			continue
+		}
+		if completion.Flag == nil {
+			completion.Flag = make(map[string][]string)
+		}
+		completion.Flag[name] = action.spec
+	}
+	return completion
+}
+
+// actionSpec creates an Action from values and macros of a spec file.
+func actionSpec(values []string) Action {
+	return ActionCallback(func(c Context) Action {
+		batch := Batch()
+		modifiers := make([]func(a Action) Action, 0)
+		vals := make([]string, 0)
+		for _, value := range values {
+			if !strings.HasPrefix(value, "$") {
+				splitted := append(strings.SplitN(value, "\t", 3), "", "")
+				vals = append(vals, splitted[0], splitted[1], splitted[2])
+				continue
+			}
+
+			name, args, err := parseMacro(value)
+			if err != nil {
+				return ActionMessage(err.Error())
+			}
+
+			switch name {
+			case "directories":
+				batch = append(batch, ActionDirectories())
+			case "executables":
+				batch = append(batch, ActionExecutables())
+			case "files":
+				batch = append(batch, ActionFiles(args...))
+			case "multiparts":
+				modifiers = append(modifiers, func(a Action) Action { return a.MultiParts(args...) })
+			default:
+				return ActionMessage("unknown macro: '%v'", value)
+			}
+		}
+		batch = append(batch, ActionStyledValuesDescribed(vals...))
+
+		a := batch.ToA()
+		for _, modifier := range modifiers {
+			a = modifier(a)
+		}
+		return a
+	}).withSpec(values...)
+}
+
+func addSpecFlag(fs *pflag.FlagSet, definition, usage string) error {
+	f, err := spec.ParseFlag(definition, usage)
+	if err != nil {
+		return err
+	}
+
+	name := f.Longhand
+	if name == "" {
+		name = f.Shorthand
+	}
+
+	tmp := pflag.NewFlagSet("", pflag.ContinueOnError)
+	switch {
+	case f.Value && f.Repeatable:
+		tmp.StringArray(name, []string{}, f.Usage)
+	case f.Value:
+		tmp.String(name, "", f.Usage)
+	case f.Repeatable:
+		tmp.Count(name, f.Usage)
+	default:
+		tmp.Bool(name, false, f.Usage)
+	}
+
+	flag := tmp.Lookup(name)
+	flag.Shorthand = f.Shorthand
+	if f.Optarg {
+		flag.NoOptDefVal = " "
+	}
+	if err := (pflagfork.Flag{Flag: flag}).SetMode(f.Mode); err != nil {
+		return err
+	}
+	if f.NargsMin != 0 {
+		if err := (pflagfork.Flag{Flag: flag}).SetNargs(f.NargsMin, f.NargsMax); err != nil {
+			return err
+		}
+	}
+
+	if fs.Lookup(flag.Name) != nil {
+		return fmt.Errorf("duplicate flag: '%v'", definition)
+	}
+	if flag.Shorthand != "" && fs.ShorthandLookup(flag.Shorthand) != nil {
+		return fmt.Errorf("duplicate shorthand: '%v'", definition)
+	}
+	fs.AddFlag(flag)
+	return nil
+}
+
+func addSpecFlags(fs *pflag.FlagSet, flags map[string]string) error {
+	definitions := make([]string, 0, len(flags))
+	for definition := range flags {
+		definitions = append(definitions, definition)
+	}
+	sort.Strings(definitions)
+
+	for _, definition := range definitions {
+		if err := addSpecFlag(fs, definition, flags[definition]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromSpec creates a command with completions from the content of a spec file.
+//
+//	cmd, err := carapace.FromSpec(content)
+//	if err != nil {
+//		return err
+//	}
+//	return cmd.Execute()
+func FromSpec(content []byte) (*cobra.Command, error) {
+	s, err := spec.Load(content)
+	if err != nil {
+		return nil, err
+	}
+	return fromSpec(s)
+}
+
+func fromSpec(s spec.Command) (*cobra.Command, error) {
+	cmd := &cobra.Command{
+		Use:     s.Name,
+		Short:   s.Description,
+		Aliases: s.Aliases,
+		GroupID: s.Group,
+		Run:     func(cmd *cobra.Command, args []string) {},
+	}
+
+	if err := addSpecFlags(cmd.Flags(), s.Flags); err != nil {
+		return nil, err
+	}
+	if err := addSpecFlags(cmd.PersistentFlags(), s.PersistentFlags); err != nil {
+		return nil, err
+	}
+
+	for _, subSpec := range s.Commands {
+		subcmd, err := fromSpec(subSpec)
+		if err != nil {
+			return nil, err
+		}
+		if subcmd.GroupID != "" && !cmd.ContainsGroup(subcmd.GroupID) {
+			cmd.AddGroup(&cobra.Group{ID: subcmd.GroupID, Title: subcmd.GroupID})
+		}
+		cmd.AddCommand(subcmd)
+	}
+
+	c := Gen(cmd)
+
+	flagActions := make(ActionMap)
+	for name, values := range s.Completion.Flag {
+		flagActions[name] = actionSpec(values)
+	}
+	c.FlagCompletion(flagActions)
+
+	positional := make([]Action, 0, len(s.Completion.Positional))
+	for _, values := range s.Completion.Positional {
+		positional = append(positional, actionSpec(values))
+	}
+	c.PositionalCompletion(positional...)
+
+	dash := make([]Action, 0, len(s.Completion.Dash))
+	for _, values := range s.Completion.Dash {
+		dash = append(dash, actionSpec(values))
+	}
+	c.DashCompletion(dash...)
+
+	if s.Completion.PositionalAny != nil {
+		c.PositionalAnyCompletion(actionSpec(s.Completion.PositionalAny))
+	}
+	if s.Completion.DashAny != nil {
+		c.DashAnyCompletion(actionSpec(s.Completion.DashAny))
+	}
+	return cmd, nil
+}
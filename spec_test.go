@@ -0,0 +1,133 @@
+package carapace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rsteube/carapace/internal/assert"
+	"github.com/rsteube/carapace/internal/pflagfork"
+	"github.com/rsteube/carapace/internal/shell/spec"
+	"github.com/rsteube/carapace/pkg/style"
+	"github.com/spf13/cobra"
+)
+
+func TestSpecCompletion(t *testing.T) {
+	cmd := &cobra.Command{
+		Use: "spec",
+		Run: func(cmd *cobra.Command, args []string) {},
+	}
+	cmd.Flags().String("files", "", "")
+	cmd.Flags().String("callback", "", "")
+	cmd.Flags().String("styled", "", "")
+
+	Gen(cmd).FlagCompletion(ActionMap{
+		"files":    ActionFiles(".md", "go.mod"),
+		"callback": ActionCallback(func(c Context) Action { return ActionValues() }),
+		"styled":   ActionStyledValues("red", "red", "blue", "blue"),
+	})
+
+	Gen(cmd).PositionalCompletion(
+		ActionValuesDescribed("one", "first", "two", "second"),
+		ActionCallback(func(c Context) Action { return ActionValues() }),
+		ActionMultiParts(":", func(c Context) Action { return ActionValues() }),
+		ActionValues("a:b", "a:c").MultiParts(":"),
+		ActionValues("styled").Style(style.Red),
+		ActionValues("cached").Cache(time.Hour),
+		ActionValues("modified").Tag("tag").NoSpace().Usage("usage"),
+	)
+	Gen(cmd).PositionalAnyCompletion(ActionDirectories())
+	Gen(cmd).DashAnyCompletion(ActionExecutables())
+
+	assert.Equal(t, `name: spec
+flags:
+    --callback=: ""
+    --files=: ""
+    --styled=: ""
+completion:
+    flag:
+        files:
+            - $files([.md, go.mod])
+        styled:
+            - "red\t\tred"
+            - "blue\t\tblue"
+    positional:
+        - - "one\tfirst"
+          - "two\tsecond"
+        - []
+        - []
+        - - a:b
+          - a:c
+          - $multiparts([:])
+        - - "styled\t\tred"
+        - - cached
+        - - modified
+    positionalany:
+        - $directories
+    dashany:
+        - $executables
+`, spec.Snippet(cmd))
+}
+
+func TestFromSpec(t *testing.T) {
+	content := `name: root
+description: root command
+persistentflags:
+    -p, --persistent: persistent flag
+completion:
+    positional:
+        - - "one\tfirst"
+          - "two\tsecond\tblue"
+commands:
+    - name: sub
+      aliases:
+        - alias
+      description: subcommand
+      group: main
+      flags:
+        --count*: count flag
+        --optarg?: optarg flag
+        --point*=3: point flag
+        --range=1..: range flag
+        -a, --array*=: array flag
+        -s, --string=: string flag
+      completion:
+        flag:
+            array:
+                - a1
+                - a2
+            string:
+                - $files([.md, go.mod])
+        positionalany:
+            - $multiparts([:])
+`
+	cmd, err := FromSpec([]byte(content))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, content, spec.Snippet(cmd))
+
+	subcmd, _, _ := cmd.Find([]string{"alias"})
+	if subcmd.Name() != "sub" || !cmd.ContainsGroup("main") {
+		t.Error("subcommand missing")
+	}
+
+	assertEqual(t,
+		ActionStyledValuesDescribed("one", "first", "", "two", "second", "blue").Invoke(Context{}),
+		storage.getPositional(cmd, 0).Invoke(Context{}),
+	)
+	assertEqual(t,
+		ActionValues("a1", "a2").Usage("array flag").Invoke(Context{}),
+		storage.getFlag(subcmd, "array").Invoke(Context{}),
+	)
+
+	if min, max := (pflagfork.Flag{Flag: subcmd.Flag("point")}).NargsRange(); min != 3 || max != 3 {
+		t.Errorf("invalid nargs for point: %v..%v", min, max)
+	}
+	if min, max := (pflagfork.Flag{Flag: subcmd.Flag("range")}).NargsRange(); min != 1 || max != -1 {
+		t.Errorf("invalid nargs for range: %v..%v", min, max)
+	}
+
+	if _, err := FromSpec([]byte("name: invalid\nflags:\n    ---invalid: invalid\n")); err == nil {
+		t.Error("should fail for invalid flag definition")
+	}
+}
@@ -1,11 +1,11 @@
 package carapace
 
 import (
-	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/rsteube/carapace/internal/common"
-	"github.com/rsteube/carapace/internal/uid"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -15,40 +15,96 @@ import (
 type entry struct {
 	flag          ActionMap
 	positional    []Action
-	positionalAny Action
+	positionalAny *Action
 	dash          []Action
-	dashAny       Action
+	dashAny       *Action
 	preinvoke     func(cmd *cobra.Command, flag *pflag.Flag, action Action) Action
 	prerun        func(cmd *cobra.Command, args []string)
 	bridged       bool
+	abbreviate    bool
 }
 
-type _storage map[*cobra.Command]*entry
+// orEmpty returns the referenced Action or an empty one if nil.
+func orEmpty(a *Action) Action {
+	if a == nil {
+		return Action{}
+	}
+	return *a
+}
+
+type _storage struct {
+	mutex   sync.RWMutex
+	entries map[*cobra.Command]*entry
+	bridged map[uintptr]bool // code pointers of completion functions registered in cobra by bridging
+}
+
+// get returns a copy of the entry for given command.
+func (s *_storage) get(cmd *cobra.Command) entry {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if e, ok := s.entries[cmd]; ok {
+		return *e
+	}
+	return entry{}
+}
+
+// update modifies the entry for given command while holding the lock.
+// Fields must be replaced instead of modified in place as copies returned by get share them.
+func (s *_storage) update(cmd *cobra.Command, f func(e *entry)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
-func (s _storage) get(cmd *cobra.Command) (e *entry) {
-	var ok bool
-	if e, ok = s[cmd]; !ok {
+	e, ok := s.entries[cmd]
+	if !ok {
 		e = &entry{}
-		s[cmd] = e
+		s.entries[cmd] = e
 	}
-	return
+	f(e)
 }
 
-func (s _storage) bridge(cmd *cobra.Command) {
-	if entry := storage.get(cmd); !entry.bridged {
-		cobra.OnInitialize(func() {
-			registerValidArgsFunction(cmd)
-			registerFlagCompletion(cmd)
-		})
-		entry.bridged = true
+func (s *_storage) bridge(cmd *cobra.Command) {
+	s.update(cmd, func(e *entry) {
+		if !e.bridged {
+			cobra.OnInitialize(func() {
+				registerValidArgsFunction(cmd)
+				registerFlagCompletion(cmd)
+			})
+			e.bridged = true
+		}
+	})
+}
+
+// markBridged marks given cobra completion function as registered by bridging.
+// All closures of the same function literal share the code pointer so it applies to any command and flag.
+func (s *_storage) markBridged(f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bridged[reflect.ValueOf(f).Pointer()] = true
+}
+
+// isBridged returns true if given cobra completion function was registered by bridging.
+func (s *_storage) isBridged(f func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) bool {
+	if f == nil {
+		return false
 	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.bridged[reflect.ValueOf(f).Pointer()]
 }
 
-func (s _storage) getFlag(cmd *cobra.Command, name string) Action {
+func (s *_storage) getFlag(cmd *cobra.Command, name string) Action {
 	if flag := cmd.LocalFlags().Lookup(name); flag == nil && cmd.HasParent() {
 		return s.getFlag(cmd.Parent(), name)
 	} else {
-		a := s.preinvoke(cmd, flag, s.get(cmd).flag[name])
+		action, ok := s.get(cmd).flag[name]
+		if !ok {
+			action = cobraFlag(cmd, name)
+		}
+		a := s.preinvoke(cmd, flag, action)
 
 		return ActionCallback(func(c Context) Action { // TODO verify order of execution is correct
 			invoked := a.Invoke(c)
@@ -60,14 +116,25 @@ func (s _storage) getFlag(cmd *cobra.Command, name string) Action {
 	}
 }
 
-func (s _storage) preRun(cmd *cobra.Command, args []string) {
+// abbreviate checks if abbreviated flags are allowed for given command (or one of its parents).
+func (s *_storage) abbreviate(cmd *cobra.Command) bool {
+	if s.get(cmd).abbreviate {
+		return true
+	}
+	if cmd.HasParent() {
+		return s.abbreviate(cmd.Parent())
+	}
+	return false
+}
+
+func (s *_storage) preRun(cmd *cobra.Command, args []string) {
 	if entry := s.get(cmd); entry.prerun != nil {
 		LOG.Printf("executing PreRun for %#v with args %#v", cmd.Name(), args)
 		entry.prerun(cmd, args)
 	}
 }
 
-func (s _storage) preinvoke(cmd *cobra.Command, flag *pflag.Flag, action Action) Action {
+func (s *_storage) preinvoke(cmd *cobra.Command, flag *pflag.Flag, action Action) Action {
 	a := action
 	if entry := s.get(cmd); entry.preinvoke != nil {
 		a = ActionCallback(func(c Context) Action {
@@ -81,7 +148,7 @@ func (s _storage) preinvoke(cmd *cobra.Command, flag *pflag.Flag, action Action)
 	return a
 }
 
-func (s _storage) getPositional(cmd *cobra.Command, index int) Action {
+func (s *_storage) getPositional(cmd *cobra.Command, index int) Action {
 	entry := s.get(cmd)
 	isDash := common.IsDash(cmd)
 
@@ -89,12 +156,14 @@ func (s _storage) getPositional(cmd *cobra.Command, index int) Action {
 	switch {
 	case !isDash && len(entry.positional) > index:
 		a = s.preinvoke(cmd, nil, entry.positional[index])
+	case !isDash && entry.positionalAny != nil:
+		a = s.preinvoke(cmd, nil, *entry.positionalAny)
 	case !isDash:
-		a = s.preinvoke(cmd, nil, entry.positionalAny)
+		a = s.preinvoke(cmd, nil, cobraPositional(cmd, index))
 	case len(entry.dash) > index:
 		a = s.preinvoke(cmd, nil, entry.dash[index])
 	default:
-		a = s.preinvoke(cmd, nil, entry.dashAny)
+		a = s.preinvoke(cmd, nil, orEmpty(entry.dashAny))
 	}
 
 	return ActionCallback(func(c Context) Action {
@@ -106,16 +175,15 @@ func (s _storage) getPositional(cmd *cobra.Command, index int) Action {
 	})
 }
 
-func (s _storage) check() []string {
-	errors := make([]string, 0)
-	for cmd, entry := range s {
-		for name := range entry.flag {
-			if flag := cmd.LocalFlags().Lookup(name); flag == nil {
-				errors = append(errors, fmt.Sprintf("unknown flag for %s: %s\n", uid.Command(cmd), name))
-			}
-		}
+func (s *_storage) check() []Issue {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	issues := make([]Issue, 0)
+	for cmd, entry := range s.entries {
+		issues = append(issues, lint(cmd, *entry)...)
 	}
-	return errors
+	return issues
 }
 
-var storage = make(_storage)
+var storage = _storage{entries: make(map[*cobra.Command]*entry), bridged: make(map[uintptr]bool)}
@@ -1,9 +1,12 @@
 package carapace
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func TestGetFlag(t *testing.T) {
@@ -63,7 +66,16 @@ func TestCheck(t *testing.T) {
 		"flag": ActionValues("a", "b"),
 	})
 
-	if len(storage.check()) != 0 {
+	issues := func() (count int) {
+		for _, issue := range storage.check() {
+			if issue.Command == cmd {
+				count++
+			}
+		}
+		return
+	}
+
+	if issues() != 0 {
 		t.Error("check should succeed")
 	}
 
@@ -71,7 +83,61 @@ func TestCheck(t *testing.T) {
 		"unknown-flag": ActionValues("a", "b"),
 	})
 
-	if len(storage.check()) != 1 {
+	if issues() != 1 {
 		t.Error("check should fail")
 	}
 }
+
+func TestStorageConcurrent(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("flag", "", "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			subcmd := &cobra.Command{}
+			Gen(subcmd).PositionalCompletion(ActionValues("pos"))
+			Gen(cmd).FlagCompletion(ActionMap{
+				fmt.Sprintf("flag%v", i): ActionValues("a"),
+			})
+			Gen(cmd).PreRun(func(cmd *cobra.Command, args []string) {})
+			storage.getFlag(cmd, "flag").Invoke(Context{})
+			storage.getPositional(subcmd, 0).Invoke(Context{})
+		}(i)
+	}
+	wg.Wait()
+
+	if count := len(Registered(cmd).Flag); count != 10 {
+		t.Errorf("expected 10 flags, got %v", count)
+	}
+}
+
+func TestRegistered(t *testing.T) {
+	cmd := &cobra.Command{}
+
+	if r := Registered(cmd); len(r.Flag) != 0 || len(r.Positional) != 0 || r.PositionalAny != nil || r.PreRun || r.PreInvoke {
+		t.Errorf("expected empty registration: %#v", r)
+	}
+
+	Gen(cmd).FlagCompletion(ActionMap{
+		"flag": ActionValues("flag"),
+	})
+	Gen(cmd).PositionalCompletion(ActionValues("pos1"))
+	Gen(cmd).DashAnyCompletion(ActionValues("dashAny"))
+	Gen(cmd).PreInvoke(func(cmd *cobra.Command, flag *pflag.Flag, action Action) Action { return action })
+
+	r := Registered(cmd)
+	assertEqual(t, ActionValues("flag").Invoke(Context{}), r.Flag["flag"].Invoke(Context{}))
+	assertEqual(t, ActionValues("pos1").Invoke(Context{}), r.Positional[0].Invoke(Context{}))
+	assertEqual(t, ActionValues("dashAny").Invoke(Context{}), r.DashAny.Invoke(Context{}))
+	if r.PositionalAny != nil || len(r.Dash) != 0 || r.PreRun || !r.PreInvoke {
+		t.Errorf("unexpected registration: %#v", r)
+	}
+
+	r.Flag["other"] = ActionValues()
+	if _, ok := Registered(cmd).Flag["other"]; ok {
+		t.Error("registration should be a copy")
+	}
+}
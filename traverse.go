@@ -1,6 +1,8 @@
 package carapace
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/rsteube/carapace/internal/common"
@@ -26,12 +28,32 @@ func (f _inFlag) Consumes(arg string) bool {
 		return false
 	case len(f.Args) == 0:
 		return true
-	case f.Nargs() > 1 && len(f.Args) < f.Nargs():
-		return true
-	case f.Nargs() < 0 && !strings.HasPrefix(arg, "-"):
+	}
+
+	switch min, max := f.NargsRange(); {
+	case max >= 0 && len(f.Args) >= max:
+		return false
+	case len(f.Args) < min:
 		return true
 	default:
-		return false
+		return !strings.HasPrefix(arg, "-") // optional arguments up to max
+	}
+}
+
+// arg returns the flag argument in a form the parser understands.
+// Additional arguments are passed as repeated flag unless the parser supports nargs itself (carapace-pflag).
+func (f _inFlag) arg(arg string) string {
+	switch {
+	case len(f.Args) == 0:
+		return arg
+	case f.Nargs() != 0 && f.Annotations[pflagfork.AnnotationNargs] == nil:
+		return arg
+	case f.Mode() == pflagfork.Default:
+		return "--" + f.Name + string(f.OptargDelimiter()) + arg
+	case f.Mode() == pflagfork.NameAsShorthand:
+		return "-" + f.Name + string(f.OptargDelimiter()) + arg
+	default:
+		return "-" + f.Shorthand + string(f.OptargDelimiter()) + arg
 	}
 }
 
@@ -44,19 +66,23 @@ func traverse(c *cobra.Command, args []string) (Action, Context) {
 		c.FParseErrWhitelist.UnknownFlags = true
 	}
 
-	inArgs := []string{} // args consumed by current command
-	var inFlag *_inFlag  // last encountered flag that still expects arguments
-	c.LocalFlags()       // TODO force  c.mergePersistentFlags() which is missing from c.Flags()
-	fs := pflagfork.FlagSet{FlagSet: c.Flags()}
+	inArgs := []string{}    // args consumed by current command
+	inFlags := []*_inFlag{} // flag each of inArgs belongs to (nil for positionals)
+	var inFlag *_inFlag     // last encountered flag that still expects arguments
+	c.LocalFlags()          // TODO force  c.mergePersistentFlags() which is missing from c.Flags()
+	fs := pflagfork.FlagSet{FlagSet: c.Flags(), Abbreviate: storage.abbreviate(c)}
 
 	context := NewContext(args...)
+	context.flagSet = c.Flags()
+	afterPositional := false // a positional was encountered (flags are no longer parsed if not interspersed)
 loop:
 	for i, arg := range context.Args {
 		switch {
 		// flag argument
 		case inFlag != nil && inFlag.Consumes(arg):
 			LOG.Printf("arg %#v is a flag argument\n", arg)
-			inArgs = append(inArgs, arg)
+			inArgs = append(inArgs, inFlag.arg(arg))
+			inFlags = append(inFlags, inFlag)
 			inFlag.Args = append(inFlag.Args, arg)
 
 			if !inFlag.Consumes("") {
@@ -64,6 +90,12 @@ loop:
 			}
 			continue
 
+		// positional (not interspersed)
+		case afterPositional && !fs.IsInterspersed():
+			LOG.Printf("arg %#v is a positional since flags are not interspersed\n", arg)
+			inArgs = append(inArgs, arg)
+			inFlags = append(inFlags, nil)
+
 		// dash
 		case arg == "--":
 			LOG.Printf("arg %#v is dash\n", arg)
@@ -73,47 +105,70 @@ loop:
 		// flag
 		case !c.DisableFlagParsing && strings.HasPrefix(arg, "-"):
 			LOG.Printf("arg %#v is a flag\n", arg)
-			inArgs = append(inArgs, arg)
+			inArgs = append(inArgs, fs.Normalize(arg))
 			inFlag = &_inFlag{
 				Flag: fs.LookupArg(arg),
 				Args: []string{},
 			}
+			inFlags = append(inFlags, inFlag)
 
-			if inFlag.Flag == nil {
+			switch {
+			case inFlag.Flag == nil:
 				LOG.Printf("flag %#v is unknown", arg)
+			case inFlag.TakesValue() && !inFlag.IsOptarg() && (strings.HasPrefix(arg, "--") || !fs.IsPosix()) && strings.ContainsRune(arg, inFlag.OptargDelimiter()):
+				_, value := inFlag.Split(arg)
+				LOG.Printf("flag %#v contains its argument %#v\n", arg, value)
+				inFlag.Args = append(inFlag.Args, value)
 			}
 			continue
 
 		// subcommand
 		case subcommand(c, arg) != nil:
 			LOG.Printf("arg %#v is a subcommand\n", arg)
+			subcmd := subcommand(c, arg)
 
+			forwarded := []string{}
 			switch {
 			case c.DisableFlagParsing:
 				LOG.Printf("flag parsing disabled for %#v\n", c.Name())
 
 			default:
-				LOG.Printf("parsing flags for %#v with args %#v\n", c.Name(), inArgs)
-				if err := c.ParseFlags(inArgs); err != nil {
+				toParse := inArgs
+				if !c.Root().TraverseChildren {
+					// like cobra the subcommand parses inherited (and unknown) flags given before it
+					toParse, forwarded = forwardFlags(subcmd, inArgs, inFlags)
+					LOG.Printf("forwarding flags %#v to %#v\n", forwarded, subcmd.Name())
+				}
+
+				LOG.Printf("parsing flags for %#v with args %#v\n", c.Name(), toParse)
+				if err := c.ParseFlags(toParse); err != nil {
 					return ActionMessage(err.Error()), context
 				}
 				context.Args = c.Flags().Args()
 			}
 
-			return traverse(subcommand(c, arg), args[i+1:])
+			return traverse(subcmd, append(forwarded, args[i+1:]...))
+
+		// unknown subcommand
+		case !afterPositional && unknownSubcommand(c, arg) != nil:
+			LOG.Printf("arg %#v is an unknown subcommand\n", arg)
+			return ActionMessage(unknownSubcommand(c, arg).Error()), context
 
 		// positional
 		default:
 			LOG.Printf("arg %#v is a positional\n", arg)
 			inArgs = append(inArgs, arg)
+			inFlags = append(inFlags, nil)
+			afterPositional = true
 		}
 	}
+	interspersed := !afterPositional || fs.IsInterspersed()
 
 	toParse := inArgs
 	if inFlag != nil && len(inFlag.Args) == 0 && inFlag.Consumes("") {
 		LOG.Printf("removing arg %#v since it is a flag missing its argument\n", toParse[len(toParse)-1])
 		toParse = toParse[:len(toParse)-1]
-	} else if fs.IsShorthandSeries(context.Value) {
+	} else if interspersed && fs.IsShorthandSeries(context.Value) {
 		LOG.Printf("arg %#v is a shorthand flag series", context.Value)
 		localInFlag := &_inFlag{
 			Flag: fs.LookupArg(context.Value),
@@ -148,7 +203,11 @@ loop:
 		context.Args = c.Flags().Args()[c.ArgsLenAtDash():]
 		LOG.Printf("context: %#v\n", context.Args)
 
-		return storage.getPositional(c, len(context.Args)), context
+		if !acceptsMoreArgs(c, len(c.Flags().Args())) {
+			LOG.Printf("no more args accepted by %#v\n", c.Name())
+			return actionNoMoreArgs(c), context
+		}
+		return actionMissingRequired(c, storage.getPositional(c, len(context.Args))), context
 
 	// flag argument
 	case inFlag != nil && inFlag.Consumes(context.Value):
@@ -157,7 +216,7 @@ loop:
 		return storage.getFlag(c, inFlag.Name), context
 
 	// flag
-	case !c.DisableFlagParsing && strings.HasPrefix(context.Value, "-"):
+	case !c.DisableFlagParsing && interspersed && strings.HasPrefix(context.Value, "-"):
 		if f := fs.LookupArg(context.Value); f != nil && f.IsOptarg() && strings.Contains(context.Value, string(f.OptargDelimiter())) {
 			LOG.Printf("completing optional flag argument for arg %#v\n", context.Value)
 			prefix, optarg := f.Split(context.Value)
@@ -176,17 +235,114 @@ loop:
 	// positional or subcommand
 	default:
 		LOG.Printf("completing positionals and subcommands for arg %#v\n", context.Value)
-		batch := Batch(storage.getPositional(c, len(context.Args)))
+		batch := Batch()
+		if acceptsMoreArgs(c, len(context.Args)) {
+			batch = append(batch, storage.getPositional(c, len(context.Args)))
+		}
 		if c.HasAvailableSubCommands() && len(context.Args) == 0 {
 			batch = append(batch, actionSubcommands(c))
 		}
-		return batch.ToA(), context
+		if len(batch) == 0 {
+			LOG.Printf("no more args accepted by %#v\n", c.Name())
+			return actionNoMoreArgs(c), context
+		}
+		return actionMissingRequired(c, batch.ToA()), context
+	}
+}
+
+// forwardFlags splits given args into those parsed by the current command and flags (with their arguments)
+// either inherited by the subcommand or unknown to the current one, which are parsed by the subcommand instead.
+func forwardFlags(subcmd *cobra.Command, args []string, flags []*_inFlag) (local, forwarded []string) {
+	inherited := subcmd.InheritedFlags()
+	local = []string{}
+	forwarded = []string{}
+	for index, arg := range args {
+		switch f := flags[index]; {
+		case f == nil:
+			local = append(local, arg)
+		case f.Flag == nil, inherited.Lookup(f.Name) != nil:
+			forwarded = append(forwarded, arg)
+		default:
+			local = append(local, arg)
+		}
 	}
+	return
 }
 
+// subcommand resolves arg to a subcommand like cobra does (name, alias or unique prefix if `cobra.EnablePrefixMatching` is set).
 func subcommand(cmd *cobra.Command, arg string) *cobra.Command {
-	if subcommand, _, _ := cmd.Find([]string{arg}); subcommand != cmd {
-		return subcommand
+	matches := make([]*cobra.Command, 0)
+	for _, subcmd := range cmd.Commands() {
+		if commandNameMatches(subcmd.Name(), arg) || subcmd.HasAlias(arg) {
+			return subcmd
+		}
+		if cobra.EnablePrefixMatching && hasNameOrAliasPrefix(subcmd, arg) {
+			matches = append(matches, subcmd)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0]
 	}
 	return nil
 }
+
+func commandNameMatches(s string, t string) bool {
+	if cobra.EnableCaseInsensitive {
+		return strings.EqualFold(s, t)
+	}
+	return s == t
+}
+
+func hasNameOrAliasPrefix(cmd *cobra.Command, prefix string) bool {
+	if strings.HasPrefix(cmd.Name(), prefix) {
+		return true
+	}
+	for _, alias := range cmd.Aliases {
+		if strings.HasPrefix(alias, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownSubcommand returns an error if arg is rejected by cmd as positional and thus an unknown subcommand.
+func unknownSubcommand(cmd *cobra.Command, arg string) error {
+	switch {
+	case !cmd.HasAvailableSubCommands():
+		return nil
+	case cmd.Args == nil && cmd.HasParent():
+		return nil // legacy: only the root command rejects arbitrary args
+	case cmd.Args == nil && hasPositionalCompletion(cmd):
+		return nil // explicitly defined positional completion takes precedence over legacy validation
+	case cmd.Args != nil && acceptsMoreArgs(cmd, 0):
+		return nil
+	}
+
+	msg := fmt.Sprintf("unknown command %#v for %#v", arg, cmd.CommandPath())
+	if suggestions := suggestionsFor(cmd, arg); len(suggestions) > 0 {
+		msg += fmt.Sprintf(" - did you mean %v?", strings.Join(suggestions, ", "))
+	}
+	return errors.New(msg)
+}
+
+func hasPositionalCompletion(cmd *cobra.Command) bool {
+	entry := storage.get(cmd)
+	return len(entry.positional) > 0 || entry.positionalAny != nil
+}
+
+// suggestionsFor returns subcommands suggested for arg (`SuggestFor` and levenshtein distance).
+func suggestionsFor(cmd *cobra.Command, arg string) []string {
+	if cmd.DisableSuggestions {
+		return nil
+	}
+	if cmd.SuggestionsMinimumDistance <= 0 {
+		cmd.SuggestionsMinimumDistance = 2 // same default as cobra
+	}
+
+	suggestions := make([]string, 0)
+	for _, suggestion := range cmd.SuggestionsFor(arg) {
+		suggestions = append(suggestions, fmt.Sprintf("%#v", suggestion))
+	}
+	return suggestions
+}
@@ -0,0 +1,422 @@
+package carapace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rsteube/carapace/internal/pflagfork"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type traverseState struct {
+	persistent    string
+	subpersistent string
+	slice         string
+	changed       bool
+	visited       []string
+	args          []string
+}
+
+func traverseCmd(traverseChildren bool) (*cobra.Command, *traverseState) {
+	state := &traverseState{}
+
+	root := &cobra.Command{Use: "root", TraverseChildren: traverseChildren, Run: func(*cobra.Command, []string) {}}
+	root.PersistentFlags().String("persistent", "", "")
+	root.PersistentFlags().StringSlice("slice", []string{}, "")
+	root.Flags().Bool("local", false, "")
+
+	sub := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	sub.Flags().String("subflag", "", "")
+	sub.PersistentFlags().String("subpersistent", "", "")
+	root.AddCommand(sub)
+
+	subsub := &cobra.Command{Use: "subsub", Run: func(*cobra.Command, []string) {}}
+	subsub.Flags().String("subsubflag", "", "")
+	sub.AddCommand(subsub)
+
+	record := ActionCallback(func(c Context) Action {
+		state.persistent = c.GetString("persistent")
+		state.subpersistent = c.GetString("subpersistent")
+		state.slice = strings.Join(c.GetStringSlice("slice"), ",")
+		state.changed = c.FlagChanged("persistent")
+		state.visited = []string{}
+		c.flags().Visit(func(f *pflag.Flag) { state.visited = append(state.visited, f.Name) })
+		state.args = c.Args
+		return ActionValues()
+	})
+
+	Gen(root)
+	Gen(sub).FlagCompletion(ActionMap{"subflag": record})
+	Gen(sub).PositionalAnyCompletion(record)
+	Gen(subsub).FlagCompletion(ActionMap{"subsubflag": record})
+	Gen(subsub).PositionalAnyCompletion(record)
+	return root, state
+}
+
+func TestTraversePersistentFlags(t *testing.T) {
+	for _, traverseChildren := range []bool{false, true} {
+		for args, expected := range map[string]traverseState{
+			"--persistent p sub --subflag ":                          {persistent: "p", changed: true},
+			"sub --persistent p --subflag ":                          {persistent: "p", changed: true},
+			"--persistent=p sub --subflag ":                          {persistent: "p", changed: true},
+			"--persistent p sub --subpersistent s subsub ":           {persistent: "p", subpersistent: "s", changed: true},
+			"--persistent p sub subsub --subpersistent s ":           {persistent: "p", subpersistent: "s", changed: true},
+			"sub --subpersistent=s subsub --subsubflag ":             {subpersistent: "s"},
+			"--slice a sub --slice b subsub --slice c --subsubflag ": {slice: "a,b,c"},
+			"--local sub --subflag ":                                 {},
+		} {
+			root, state := traverseCmd(traverseChildren)
+			if _, err := complete(root, append([]string{"export", "root"}, strings.Split(args, " ")...)); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			if state.persistent != expected.persistent ||
+				state.subpersistent != expected.subpersistent ||
+				state.slice != expected.slice ||
+				state.changed != expected.changed {
+				t.Errorf("traverseChildren=%v %#v: expected %+v, got %+v", traverseChildren, args, expected, *state)
+			}
+		}
+	}
+}
+
+func TestTraverseForwardedFlags(t *testing.T) {
+	root, state := traverseCmd(false)
+	if _, err := complete(root, []string{"export", "root", "--persistent", "p", "--local", "sub", "pos", ""}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if strings.Join(state.visited, ",") != "persistent" {
+		t.Errorf("inherited flags should be parsed by the subcommand: %v", state.visited)
+	}
+	if strings.Join(state.args, ",") != "pos" {
+		t.Errorf("unexpected args: %v", state.args)
+	}
+
+	root, state = traverseCmd(true)
+	if _, err := complete(root, []string{"export", "root", "--persistent", "p", "--local", "sub", "pos", ""}); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(state.visited) != 0 {
+		t.Errorf("flags should be parsed by the parent when traversing children: %v", state.visited)
+	}
+	if strings.Join(state.args, ",") != "pos" {
+		t.Errorf("unexpected args: %v", state.args)
+	}
+}
+
+func TestTraverseInterspersed(t *testing.T) {
+	cmd := &cobra.Command{Use: "interspersed", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().BoolP("bool", "b", false, "")
+	cmd.Flags().SetInterspersed(false)
+
+	var args []string
+	Gen(cmd).PositionalAnyCompletion(ActionCallback(func(c Context) Action {
+		args = c.Args
+		return ActionValues("positional")
+	}))
+
+	if a, _ := complete(cmd, []string{"export", "interspersed", "-"}); !strings.Contains(a, `"--bool"`) {
+		t.Errorf("expected flags before the first positional: %v", a)
+	}
+
+	if a, _ := complete(cmd, []string{"export", "interspersed", "pos", "-"}); strings.Contains(a, `"--bool"`) {
+		t.Errorf("expected no flags after the first positional: %v", a)
+	}
+
+	a, err := complete(cmd, []string{"export", "interspersed", "--bool", "pos", "-b", ""})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected positional completion after the first positional: %v", a)
+	}
+	if strings.Join(args, ",") != "pos,-b" {
+		t.Errorf("expected flags after the first positional to be args: %v", args)
+	}
+}
+
+func TestTraverseArgs(t *testing.T) {
+	cmd := &cobra.Command{Use: "args [pos]", Args: cobra.MaximumNArgs(1), Run: func(*cobra.Command, []string) {}}
+	Gen(cmd).PositionalAnyCompletion(ActionValues("positional"))
+
+	if a, _ := complete(cmd, []string{"export", "args", ""}); !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected positional completion: %v", a)
+	}
+
+	a, _ := complete(cmd, []string{"export", "args", "pos", ""})
+	if strings.Contains(a, `"positional"`) || !strings.Contains(a, "no more arguments accepted") || !strings.Contains(a, `"usage":"args [pos]"`) {
+		t.Errorf("expected usage message: %v", a)
+	}
+
+	subcmd := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	cmd = &cobra.Command{Use: "noargs", Args: cobra.NoArgs, Run: func(*cobra.Command, []string) {}}
+	cmd.AddCommand(subcmd)
+	Gen(cmd)
+
+	if a, _ := complete(cmd, []string{"export", "noargs", ""}); !strings.Contains(a, `"sub"`) || strings.Contains(a, "no more arguments accepted") {
+		t.Errorf("expected subcommands: %v", a)
+	}
+
+	cmd = &cobra.Command{Use: "custom", Args: func(*cobra.Command, []string) error {
+		t.Error("custom validator should not be probed")
+		return nil
+	}, Run: func(*cobra.Command, []string) {}}
+	Gen(cmd).PositionalAnyCompletion(ActionValues("positional"))
+
+	if a, _ := complete(cmd, []string{"export", "custom", "pos", ""}); !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected positional completion: %v", a)
+	}
+}
+
+func TestTraversePrefixMatching(t *testing.T) {
+	cmd := &cobra.Command{Use: "prefix", Run: func(*cobra.Command, []string) {}}
+	configCmd := &cobra.Command{Use: "config", Run: func(*cobra.Command, []string) {}}
+	setCmd := &cobra.Command{Use: "set", Run: func(*cobra.Command, []string) {}}
+	configCmd.AddCommand(setCmd)
+	cmd.AddCommand(configCmd, &cobra.Command{Use: "copy", Run: func(*cobra.Command, []string) {}})
+	Gen(cmd)
+	Gen(setCmd).PositionalCompletion(ActionValues("key"))
+
+	cobra.EnablePrefixMatching = true
+	defer func() { cobra.EnablePrefixMatching = false }()
+
+	if a, _ := complete(cmd, []string{"export", "prefix", "conf", "set", ""}); !strings.Contains(a, `"key"`) {
+		t.Errorf("expected completion of abbreviated subcommand: %v", a)
+	}
+
+	if subcommand(cmd, "co") != nil {
+		t.Error("expected ambiguous prefix to not match")
+	}
+}
+
+func TestTraverseUnknownSubcommand(t *testing.T) {
+	cmd := &cobra.Command{Use: "unknown", Run: func(*cobra.Command, []string) {}}
+	cmd.AddCommand(
+		&cobra.Command{Use: "config", Run: func(*cobra.Command, []string) {}},
+		&cobra.Command{Use: "remove", SuggestFor: []string{"delete"}, Run: func(*cobra.Command, []string) {}},
+	)
+	Gen(cmd)
+
+	if a, _ := complete(cmd, []string{"export", "unknown", "cnofig", ""}); !strings.Contains(a, `unknown command \"cnofig\" for \"unknown\" - did you mean \"config\"?`) {
+		t.Errorf("expected levenshtein suggestion: %v", a)
+	}
+
+	if a, _ := complete(cmd, []string{"export", "unknown", "delete", ""}); !strings.Contains(a, `did you mean \"remove\"?`) {
+		t.Errorf("expected explicit suggestion: %v", a)
+	}
+
+	Gen(cmd).PositionalAnyCompletion(ActionValues("positional"))
+	if a, _ := complete(cmd, []string{"export", "unknown", "cnofig", ""}); !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected positional completion: %v", a)
+	}
+}
+
+func TestTraverseRequiredFlags(t *testing.T) {
+	requiredCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "required", Run: func(*cobra.Command, []string) {}}
+		cmd.Flags().String("required", "", "")
+		cmd.Flags().Bool("optional", false, "")
+		cmd.Flags().Bool("together1", false, "")
+		cmd.Flags().Bool("together2", false, "")
+		cmd.Flags().Bool("one1", false, "")
+		cmd.Flags().Bool("one2", false, "")
+		_ = cmd.MarkFlagRequired("required")
+		cmd.MarkFlagsRequiredTogether("together1", "together2")
+		cmd.MarkFlagsOneRequired("one1", "one2")
+		Gen(cmd).PositionalCompletion(ActionValues("positional"))
+		return cmd
+	}
+
+	a, _ := complete(requiredCmd(), []string{"export", "required", "--"})
+	for _, expected := range []string{
+		`{"value":"--required","display":"--required","style":"blue underlined","tag":"flags"}`,
+		`{"value":"--one1","display":"--one1","style":"underlined","tag":"flags"}`,
+		`{"value":"--together1","display":"--together1","tag":"other flags"}`,
+		`{"value":"--optional","display":"--optional","tag":"other flags"}`,
+	} {
+		if !strings.Contains(a, expected) {
+			t.Errorf("expected %v: %v", expected, a)
+		}
+	}
+
+	a, _ = complete(requiredCmd(), []string{"export", "required", "--together1", "--one2", "--"})
+	for _, expected := range []string{
+		`{"value":"--together2","display":"--together2","style":"underlined","tag":"flags"}`,
+		`{"value":"--one1","display":"--one1","tag":"other flags"}`,
+	} {
+		if !strings.Contains(a, expected) {
+			t.Errorf("expected %v: %v", expected, a)
+		}
+	}
+
+	for _, shell := range []string{"zsh", "fish", "powershell"} { // shells grouping values by tag
+		a, _ = complete(requiredCmd(), []string{shell, "required", "--"})
+		if required, optional := strings.LastIndex(a, "--required"), strings.LastIndex(a, "--optional"); required == -1 || optional == -1 || required > optional {
+			t.Errorf("expected required flags to be offered first [%v]: %v", shell, a)
+		}
+	}
+
+	a, _ = complete(requiredCmd(), []string{"export", "required", "--together1", ""})
+	if !strings.Contains(a, `"usage":"required [flags] (missing required flags: --one1|--one2, --required, --together2)"`) {
+		t.Errorf("expected missing required flags in usage: %v", a)
+	}
+
+	a, _ = complete(requiredCmd(), []string{"export", "required", "--required", "r", "--one1", ""})
+	if !strings.Contains(a, `"usage":""`) || !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected no missing required flags: %v", a)
+	}
+}
+
+func TestTraverseNegatableAbbreviated(t *testing.T) {
+	var color, verbose bool
+	var output string
+	gnuCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "gnu", Run: func(*cobra.Command, []string) {}}
+		cmd.Flags().Bool("color", true, "enable colors")
+		cmd.Flags().Bool("verbose", false, "verbose output")
+		cmd.Flags().Bool("version", false, "show version")
+		cmd.Flags().String("output", "", "output file")
+
+		c := Gen(cmd)
+		c.FlagAbbreviation()
+		if err := c.MarkFlagNegatable("color", "disable colors"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := c.MarkFlagNegatable("output", ""); err == nil {
+			t.Error("expected error for non-bool flag")
+		}
+		c.FlagCompletion(ActionMap{
+			"output": ActionValues("out.txt"),
+		})
+		c.PositionalAnyCompletion(ActionCallback(func(c Context) Action {
+			color, _ = c.flagSet.GetBool("color")
+			verbose, _ = c.flagSet.GetBool("verbose")
+			output, _ = c.flagSet.GetString("output")
+			return ActionValues("positional")
+		}))
+		return cmd
+	}
+
+	a, _ := complete(gnuCmd(), []string{"export", "gnu", "--"})
+	if !strings.Contains(a, `{"value":"--no-color","display":"--no-color","description":"disable colors","tag":"flags"}`) {
+		t.Errorf("expected negated flag: %v", a)
+	}
+
+	a, _ = complete(gnuCmd(), []string{"export", "gnu", "--no-color", "--verb", "--out", ""})
+	if !strings.Contains(a, `"out.txt"`) {
+		t.Errorf("expected abbreviated flag to consume its argument: %v", a)
+	}
+
+	if a, _ = complete(gnuCmd(), []string{"export", "gnu", "--no-col", "--verb", "--out=file", ""}); !strings.Contains(a, `"positional"`) {
+		t.Errorf("expected positional completion: %v", a)
+	}
+	if color || !verbose || output != "file" {
+		t.Errorf("expected flags to be parsed [color=%v verbose=%v output=%v]", color, verbose, output)
+	}
+
+	if a, _ = complete(gnuCmd(), []string{"export", "gnu", "--ver", ""}); !strings.Contains(a, "unknown flag: --ver") {
+		t.Errorf("expected ambiguous abbreviation to be unknown: %v", a)
+	}
+
+	cmd := &cobra.Command{Use: "persistent", Run: func(*cobra.Command, []string) {}}
+	cmd.PersistentFlags().Bool("color", true, "enable colors")
+	cmd.AddCommand(&cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}})
+	if err := Gen(cmd).MarkFlagNegatable("color", "disable colors"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if a, _ = complete(cmd, []string{"export", "persistent", "sub", "--"}); !strings.Contains(a, `"value":"--no-color"`) {
+		t.Errorf("expected negated persistent flag: %v", a)
+	}
+}
+
+func TestTraverseNargs(t *testing.T) {
+	nargsCmd := func() *cobra.Command {
+		cmd := &cobra.Command{Use: "nargs", Run: func(*cobra.Command, []string) {}}
+		cmd.Flags().StringSlice("point", []string{}, "")
+		cmd.Flags().StringSlice("range", []string{}, "")
+
+		c := Gen(cmd)
+		if err := c.MarkFlagNargs("point", 3, 3); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := c.MarkFlagNargs("range", 1, 2); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := c.MarkFlagNargs("range", 2, 1); err == nil {
+			t.Error("expected error for invalid range")
+		}
+		c.FlagArgsCompletion(map[string][]Action{
+			"point": {ActionValues("x"), ActionValues("y"), ActionValues("z")},
+			"range": {ActionValues("first"), ActionValues("second")},
+		})
+		c.PositionalCompletion(ActionValues("positional")) // only the first positional
+		return cmd
+	}
+
+	tests := map[string]string{
+		"--point ":           `"x"`,
+		"--point 1 ":         `"y"`,
+		"--point 1 2 ":       `"z"`,
+		"--point 1 2 3 ":     `"positional"`,
+		"--point=1 2 ":       `"z"`,
+		"--range ":           `"first"`,
+		"--range 1 ":         `"second"`,
+		"--range 1 2 ":       `"positional"`,
+		"--range 1 --point ": `"x"`,
+		"--point 1 -2 -3 ":   `"positional"`,
+	}
+	for args, expected := range tests {
+		a, _ := complete(nargsCmd(), append([]string{"export", "nargs"}, strings.Split(args, " ")...))
+		if !strings.Contains(a, expected) {
+			t.Errorf("expected %v for %#v: %v", expected, args, a)
+		}
+	}
+
+	a, _ := complete(nargsCmd(), []string{"export", "nargs", "--"})
+	if !strings.Contains(a, `{"value":"--point","display":"--point","style":"magenta","tag":"flags"}`) {
+		t.Errorf("expected multiarg style: %v", a)
+	}
+
+	persistentCmd := &cobra.Command{Use: "persistent"}
+	persistentCmd.PersistentFlags().StringSlice("point", []string{}, "")
+	subCmd := &cobra.Command{Use: "sub", Run: func(*cobra.Command, []string) {}}
+	persistentCmd.AddCommand(subCmd)
+	if err := Gen(persistentCmd).MarkFlagNargs("point", 2, 2); err != nil {
+		t.Fatal(err.Error())
+	}
+	Gen(subCmd).PositionalCompletion(ActionCallback(func(c Context) Action {
+		return ActionValues(strings.Join(c.GetStringSlice("point"), "+"))
+	}))
+	if a, _ := complete(persistentCmd, []string{"export", "persistent", "sub", "--point", "1", "2", ""}); !strings.Contains(a, `"1+2"`) {
+		t.Errorf("expected arguments of persistent flag to be parsed: %v", a)
+	}
+
+	tmp := pflag.NewFlagSet("", pflag.ContinueOnError)
+	tmp.StringSliceP("line", "l", []string{}, "")
+	if err := (pflagfork.Flag{Flag: tmp.Lookup("line")}).SetMode(pflagfork.NameAsShorthand); err != nil {
+		t.Skip(err.Error()) // requires carapace-pflag
+	}
+	cmd := &cobra.Command{Use: "nonposix", Run: func(*cobra.Command, []string) {}}
+	cmd.Flags().AddFlag(tmp.Lookup("line")) // mode needs to be set before the flag is added
+	c := Gen(cmd)
+	if err := c.MarkFlagNargs("line", 2, 2); err != nil {
+		t.Fatal(err.Error())
+	}
+	c.PositionalCompletion(ActionCallback(func(c Context) Action {
+		return ActionValues(strings.Join(c.GetStringSlice("line"), "+"))
+	}))
+
+	if a, _ := complete(cmd, []string{"export", "nonposix", "-line", "1", "2", ""}); !strings.Contains(a, `"1+2"`) {
+		t.Errorf("expected arguments of name as shorthand flag to be parsed: %v", a)
+	}
+
+	tmp = pflag.NewFlagSet("", pflag.ContinueOnError)
+	tmp.StringSlice("noshort", []string{}, "")
+	flag := &pflagfork.Flag{Flag: tmp.Lookup("noshort")}
+	_ = flag.SetMode(pflagfork.NameAsShorthand)
+	_ = flag.SetNargs(2, 2)
+	if arg := (_inFlag{Flag: flag, Args: []string{"1"}}).arg("2"); arg != "-noshort=2" {
+		t.Errorf("expected name to be used for name as shorthand flag: %v", arg)
+	}
+}